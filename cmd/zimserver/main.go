@@ -1,17 +1,34 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/gaetanlhf/ZIMServer/internal/config"
+	"github.com/gaetanlhf/ZIMServer/internal/logging"
+	"github.com/gaetanlhf/ZIMServer/internal/metrics"
+	"github.com/gaetanlhf/ZIMServer/internal/systemd"
 	"github.com/gaetanlhf/ZIMServer/internal/web"
+	"github.com/gaetanlhf/ZIMServer/internal/web/services"
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
+	zimreader "github.com/gaetanlhf/ZIMServer/internal/zim/reader"
 )
 
 var version = "dev"
@@ -19,54 +36,203 @@ var version = "dev"
 const (
 	colorReset  = "\033[0m"
 	colorRed    = "\033[31m"
-	colorGreen  = "\033[32m"
 	colorYellow = "\033[33m"
-	colorBlue   = "\033[34m"
 	colorCyan   = "\033[36m"
 )
 
 func main() {
-	tempSet := flag.NewFlagSet("", flag.ContinueOnError)
-	tempSet.Usage = func() {}
-	showHelp := tempSet.Bool("h", false, "Show this help message")
-	tempSet.Bool("help", false, "Show this help message")
-	showVersion := tempSet.Bool("v", false, "Show version")
-	tempSet.Bool("version", false, "Show version")
-
-	if err := tempSet.Parse(os.Args[1:]); err != nil {
+	logging.Setup(os.Stderr, logging.Options{Level: slog.LevelInfo, Format: "text", Color: "auto"})
+
+	if len(os.Args) < 2 {
+		runServeCommand(nil)
+		return
+	}
+
+	switch os.Args[1] {
+	case "linkcheck":
+		runLinkcheckCommand(os.Args[2:])
+	case "library":
+		runLibraryCommand(os.Args[2:])
+	default:
+		runServeCommand(os.Args[1:])
+	}
+}
+
+func runLinkcheckCommand(args []string) {
+	linkcheckCmd := flag.NewFlagSet("linkcheck", flag.ContinueOnError)
+	linkcheckCmd.Usage = func() {}
+
+	jsonOutput := linkcheckCmd.Bool("json", false, "Output the report as JSON")
+
+	if err := linkcheckCmd.Parse(args); err != nil {
 		printUsage()
 		os.Exit(1)
 	}
 
-	if *showHelp || (tempSet.Lookup("help") != nil && tempSet.Lookup("help").Value.String() == "true") {
+	if linkcheckCmd.NArg() != 1 {
+		slog.Error("Usage: zimserver linkcheck [--json] <file.zim>")
+		os.Exit(1)
+	}
+
+	file := linkcheckCmd.Arg(0)
+
+	reader, err := zimreader.NewReader(file)
+	if err != nil {
+		slog.Error("Failed to open archive", "file", file, "error", err)
+		os.Exit(1)
+	}
+
+	report := services.CheckLinks(reader)
+	reader.Close()
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		printLinkReport(file, report)
+	}
+
+	if report.Missing > 0 || report.RedirectLoops > 0 {
+		os.Exit(1)
+	}
+}
+
+func printLinkReport(file string, report *services.LinkReport) {
+	yellow, cyan, red, reset := colorYellow, colorCyan, colorRed, colorReset
+	if !logging.UseColor(os.Stdout, "auto") {
+		yellow, cyan, red, reset = "", "", "", ""
+	}
+
+	fmt.Printf("%sLink check:%s %s\n\n", yellow, reset, filepath.Base(file))
+
+	for _, page := range report.Pages {
+		fmt.Printf("%s%s%s (%d links, %s%d missing%s, %s%d redirect loops%s, %d external)\n",
+			cyan, page.Path, reset, page.Links,
+			red, page.Missing, reset,
+			red, page.RedirectLoops, reset,
+			page.External,
+		)
+		for _, link := range page.MissingLinks {
+			fmt.Printf("  %s✗%s missing: %s\n", red, reset, link)
+		}
+		for _, link := range page.LoopLinks {
+			fmt.Printf("  %s✗%s redirect loop: %s\n", red, reset, link)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("%sSummary:%s\n", yellow, reset)
+	fmt.Printf("  Articles:       %d\n", report.Articles)
+	fmt.Printf("  Links:          %d\n", report.Links)
+	fmt.Printf("  Missing:        %d\n", report.Missing)
+	fmt.Printf("  Redirect loops: %d\n", report.RedirectLoops)
+	fmt.Printf("  External:       %d\n", report.External)
+	fmt.Printf("  Duration:       %s\n", report.Duration)
+}
+
+func runLibraryCommand(args []string) {
+	if len(args) == 0 || args[0] != "export" {
+		slog.Error("Usage: zimserver library export [-o library.xml] [files/directories...]")
+		os.Exit(1)
+	}
+
+	exportCmd := flag.NewFlagSet("export", flag.ContinueOnError)
+	exportCmd.Usage = func() {}
+
+	output := exportCmd.String("o", "", "Output file")
+	exportCmd.StringVar(output, "output", "", "Output file")
+
+	if err := exportCmd.Parse(args[1:]); err != nil {
 		printUsage()
-		os.Exit(0)
+		os.Exit(1)
 	}
-	if *showVersion || (tempSet.Lookup("version") != nil && tempSet.Lookup("version").Value.String() == "true") {
-		fmt.Printf("ZIMServer %s\n", version)
-		os.Exit(0)
+
+	zimFiles := collectZimFiles(exportCmd.Args())
+	if len(zimFiles) == 0 {
+		slog.Error("No ZIM files found")
+		os.Exit(1)
 	}
 
-	if len(os.Args) < 2 {
-		logError("No ZIM files or directories specified. Use -h for help.")
+	archiveService := services.NewArchiveService()
+	for _, file := range zimFiles {
+		if _, err := archiveService.LoadZIM(file); err != nil {
+			slog.Warn("Failed to load ZIM", "file", filepath.Base(file), "error", err)
+		}
+	}
+
+	baseDir := ""
+	out := os.Stdout
+	if *output != "" {
+		absOutput, err := filepath.Abs(*output)
+		if err != nil {
+			slog.Error("Invalid output path", "path", *output, "error", err)
+			os.Exit(1)
+		}
+		baseDir = filepath.Dir(absOutput)
+
+		file, err := os.Create(absOutput)
+		if err != nil {
+			slog.Error("Failed to create library file", "path", *output, "error", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	books := archiveService.ExportLibrary(baseDir)
+	if err := services.WriteLibrary(out, books); err != nil {
+		slog.Error("Failed to write library", "error", err)
 		os.Exit(1)
 	}
-	runServeCommand(os.Args[1:])
+
+	if *output != "" {
+		logging.Success("Exported library", "archives", len(books), "path", *output)
+	}
 }
 
 func runServeCommand(args []string) {
 	serveCmd := flag.NewFlagSet("serve", flag.ContinueOnError)
 	serveCmd.Usage = func() {} // Disable default usage
 
-	host := serveCmd.String("host", "localhost", "HTTP server host")
+	configFile := serveCmd.String("config", "", "Configuration file")
+	serveCmd.StringVar(configFile, "c", "", "Configuration file (short)")
+
+	serveCmd.String("host", "localhost", "HTTP server host")
 	serveCmd.String("H", "localhost", "HTTP server host (short)")
 
-	port := serveCmd.String("port", "8080", "HTTP server port")
+	serveCmd.String("port", "8080", "HTTP server port")
 	serveCmd.String("p", "8080", "HTTP server port (short)")
 
-	serveCmd.Bool("h", false, "Show this help message")
+	listen := serveCmd.String("listen", "", "Comma-separated listen addresses (host:port, unix:/path.sock, systemd:)")
+	socketMode := serveCmd.String("socket-mode", "0660", "Permissions of Unix socket files")
+
+	backlinks := serveCmd.Bool("backlinks", false, "Build backlink indexes in the background")
+	libraryFile := serveCmd.String("library", "", "Load archives listed in a Kiwix library.xml")
+	cacheMaxAge := serveCmd.Duration("cache-max-age", 24*time.Hour, "Browser cache lifetime for archive content (0 to disable)")
+	noCompression := serveCmd.Bool("no-compression", false, "Disable gzip/zstd response compression")
+	compressionCache := serveCmd.Int64("compression-cache", 0, "Size in MB of the compressed response cache (0 to disable)")
+	noDownload := serveCmd.String("no-download", "", "Comma-separated archives that cannot be downloaded (* for all)")
+	tlsCert := serveCmd.String("tls-cert", "", "TLS certificate file")
+	tlsKey := serveCmd.String("tls-key", "", "TLS private key file")
+	tlsSelfSigned := serveCmd.String("tls-self-signed", "", "Comma-separated hostnames/IPs for a generated self-signed certificate")
+	tlsRedirectPort := serveCmd.String("tls-redirect-port", "", "Port of a plain HTTP listener redirecting to HTTPS")
+	htpasswd := serveCmd.String("htpasswd", "", "Require login against an htpasswd file (bcrypt)")
+	authTokens := serveCmd.String("auth-tokens", "", "Comma-separated name:token bearer tokens")
+	urlRoot := serveCmd.String("url-root", "", "Serve under a URL prefix such as /library")
+	trustedProxies := serveCmd.String("trusted-proxies", "", "Comma-separated proxy IPs/CIDRs (or unix) whose X-Forwarded-* headers are honoured")
+	metricsEnabled := serveCmd.Bool("metrics", false, "Expose Prometheus metrics at /metrics")
+	metricsToken := serveCmd.String("metrics-token", "", "Bearer token required to read /metrics")
+	metricsAllow := serveCmd.String("metrics-allow", "", "Comma-separated IPs/CIDRs allowed to read /metrics")
+	logLevel := serveCmd.String("log-level", "info", "Log level: debug, info, warn or error")
+	logFormat := serveCmd.String("log-format", "text", "Log format: text or json")
+	accessLogFile := serveCmd.String("access-log", "", "Write an access log to this file (- for stdout)")
+	accessLogFormat := serveCmd.String("access-log-format", "combined", "Access log format: combined or json")
+	shutdownTimeout := serveCmd.Duration("shutdown-timeout", 30*time.Second, "Time to wait for in-flight requests on shutdown")
+
+	showHelp := serveCmd.Bool("h", false, "Show this help message")
 	serveCmd.Bool("help", false, "Show this help message")
-	serveCmd.Bool("v", false, "Show version")
+	showVersion := serveCmd.Bool("v", false, "Show version")
 	serveCmd.Bool("version", false, "Show version")
 
 	if err := serveCmd.Parse(args); err != nil {
@@ -74,33 +240,239 @@ func runServeCommand(args []string) {
 		os.Exit(1)
 	}
 
-	if serveCmd.Lookup("H").Value.String() != "localhost" {
-		*host = serveCmd.Lookup("H").Value.String()
+	if *showHelp || serveCmd.Lookup("help").Value.String() == "true" {
+		printUsage()
+		os.Exit(0)
 	}
-	if serveCmd.Lookup("p").Value.String() != "8080" {
-		*port = serveCmd.Lookup("p").Value.String()
+	if *showVersion || serveCmd.Lookup("version").Value.String() == "true" {
+		fmt.Printf("ZIMServer %s\n", version)
+		os.Exit(0)
 	}
 
-	paths := serveCmd.Args()
-	allPaths := make([]string, 0)
-	allPaths = append(allPaths, paths...)
+	if *configFile == "" {
+		*configFile = os.Getenv("ZIMSERVER_CONFIG")
+	}
+
+	loadConfig := func() (*config.Config, error) {
+		cfg := config.Default()
+
+		if *configFile != "" {
+			if err := cfg.LoadFile(*configFile); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := cfg.ApplyEnv(); err != nil {
+			return nil, err
+		}
+
+		var flagErr error
+		serveCmd.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "host", "H":
+				cfg.Listen.Host = f.Value.String()
+			case "port", "p":
+				cfg.Listen.Port = f.Value.String()
+			case "listen":
+				cfg.Listen.Addresses = config.SplitList(*listen)
+			case "socket-mode":
+				cfg.Listen.SocketMode = *socketMode
+			case "backlinks":
+				cfg.Backlinks = *backlinks
+			case "library":
+				cfg.Library = *libraryFile
+			case "cache-max-age":
+				cfg.Cache.MaxAge = *cacheMaxAge
+			case "no-compression":
+				cfg.Cache.Compression = !*noCompression
+			case "compression-cache":
+				cfg.Cache.CompressionCacheMB = *compressionCache
+			case "no-download":
+				cfg.DisableDownloads(config.SplitList(*noDownload))
+			case "tls-cert":
+				cfg.TLS.Cert = *tlsCert
+			case "tls-key":
+				cfg.TLS.Key = *tlsKey
+			case "tls-self-signed":
+				cfg.TLS.SelfSigned = config.SplitList(*tlsSelfSigned)
+			case "tls-redirect-port":
+				cfg.TLS.RedirectPort = *tlsRedirectPort
+			case "htpasswd":
+				cfg.Auth.Htpasswd = *htpasswd
+			case "auth-tokens":
+				tokens, err := config.ParseTokens(config.SplitList(*authTokens))
+				if err != nil {
+					flagErr = fmt.Errorf("--auth-tokens: %w", err)
+				}
+				cfg.Auth.Tokens = tokens
+			case "url-root":
+				cfg.URLRoot = *urlRoot
+			case "trusted-proxies":
+				cfg.TrustedProxies = config.SplitList(*trustedProxies)
+			case "metrics":
+				cfg.Metrics.Enabled = *metricsEnabled
+			case "metrics-token":
+				cfg.Metrics.Token = *metricsToken
+			case "metrics-allow":
+				cfg.Metrics.Allow = config.SplitList(*metricsAllow)
+			case "log-level":
+				cfg.Logging.Level = *logLevel
+			case "log-format":
+				cfg.Logging.Format = *logFormat
+			case "access-log":
+				cfg.Logging.Access.File = *accessLogFile
+			case "access-log-format":
+				cfg.Logging.Access.Format = *accessLogFormat
+			case "shutdown-timeout":
+				cfg.ShutdownTimeout = *shutdownTimeout
+			}
+		})
+
+		if flagErr != nil {
+			return nil, flagErr
+		}
 
-	if len(allPaths) == 0 {
-		logError("No ZIM files or directories specified")
+		if serveCmd.NArg() > 0 {
+			cfg.Paths = serveCmd.Args()
+		}
+
+		return cfg, cfg.Validate()
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
 		os.Exit(1)
 	}
 
-	runServer(*host, *port, allPaths)
+	setupLogging(cfg)
+	runServer(cfg, *configFile, loadConfig)
+}
+
+func serverOptions(cfg *config.Config, sessionSecret []byte) (web.Options, []string, error) {
+	options := web.Options{
+		Backlinks:   cfg.Backlinks,
+		NoDownload:  cfg.NoDownload(),
+		CacheMaxAge: cfg.Cache.MaxAge,
+
+		Compression:          cfg.Cache.Compression,
+		CompressionCacheSize: cfg.Cache.CompressionCacheMB * 1024 * 1024,
+
+		RequestLog: cfg.Logging.Requests,
+
+		URLRoot: utils.NormalizeURLRoot(cfg.URLRoot),
+	}
+
+	for _, entry := range cfg.TrustedProxies {
+		if entry == "unix" {
+			options.TrustUnixSocket = true
+			continue
+		}
+		network, err := utils.ParseNetwork(entry)
+		if err != nil {
+			return options, nil, fmt.Errorf("invalid trusted proxy: %w", err)
+		}
+		options.TrustedProxies = append(options.TrustedProxies, network)
+	}
+
+	if cfg.Metrics.Enabled {
+		options.Metrics = &web.MetricsOptions{
+			Path:  cfg.Metrics.Path,
+			Token: cfg.Metrics.Token,
+		}
+		for _, entry := range cfg.Metrics.Allow {
+			network, err := utils.ParseNetwork(entry)
+			if err != nil {
+				return options, nil, fmt.Errorf("invalid metrics.allow entry: %w", err)
+			}
+			options.Metrics.Allow = append(options.Metrics.Allow, network)
+		}
+	}
+
+	if cfg.AuthEnabled() {
+		if cfg.Auth.SessionSecret != "" {
+			sessionSecret = []byte(cfg.Auth.SessionSecret)
+		}
+
+		auth, err := services.NewAuthService(cfg.Auth.Htpasswd, cfg.Auth.Tokens, sessionSecret, cfg.Auth.SessionTTL, cfg.Auth.Public)
+		if err != nil {
+			return options, nil, fmt.Errorf("failed to set up authentication: %w", err)
+		}
+		options.Auth = auth
+	}
+
+	if len(cfg.Access) > 0 {
+		rules := make([]services.AccessRule, 0, len(cfg.Access))
+		for _, rule := range cfg.Access {
+			rules = append(rules, services.AccessRule{Archives: rule.Archives, Allow: rule.Allow})
+		}
+
+		access, err := services.NewAccessPolicy(rules, cfg.Auth.Groups)
+		if err != nil {
+			return options, nil, fmt.Errorf("invalid access rules: %w", err)
+		}
+		options.Access = access
+	}
+
+	paths := make([]string, 0, len(cfg.Paths))
+	paths = append(paths, cfg.Paths...)
+
+	if cfg.Library != "" {
+		library, err := services.ReadLibrary(cfg.Library)
+		if err != nil {
+			return options, nil, fmt.Errorf("failed to read library %s: %w", cfg.Library, err)
+		}
+		options.Library = library
+		paths = append(paths, library.Paths()...)
+	}
+
+	return options, paths, nil
 }
 
 func printUsage() {
 	fmt.Printf("%sZIMServer - A modern and lightweight alternative to kiwix-serve for your zim files %s\n\n", colorCyan, colorReset)
 	fmt.Printf("%sUsage:%s\n", colorYellow, colorReset)
 	fmt.Println("  zimserver [options] [files/directories...]")
+	fmt.Println("  zimserver linkcheck [--json] <file.zim>")
+	fmt.Println("  zimserver library export [-o library.xml] [files/directories...]")
 	fmt.Println()
 	fmt.Printf("%sOptions:%s\n", colorYellow, colorReset)
+	fmt.Println("  -c, --config <file>      Load settings from a YAML configuration file")
 	fmt.Println("  -H, --host <host>        HTTP server host (default: localhost)")
 	fmt.Println("  -p, --port <port>        HTTP server port (default: 8080)")
+	fmt.Println("      --listen <list>      Listen addresses, comma-separated: host:port, unix:/path.sock,")
+	fmt.Println("                           systemd: or systemd:<name> (overrides --host/--port)")
+	fmt.Println("      --socket-mode <mode> Permissions of Unix socket files (default: 0660)")
+	fmt.Println("      --backlinks          Build \"What links here\" indexes in the background")
+	fmt.Println("      --library <file>     Load archives listed in a Kiwix library.xml")
+	fmt.Println("      --cache-max-age <d>  Browser cache lifetime for archive content (default: 24h, 0 disables)")
+	fmt.Println("      --no-compression     Disable gzip/zstd response compression")
+	fmt.Println("      --compression-cache <MB>")
+	fmt.Println("                           Cache compressed responses in memory (default: 0, disabled)")
+	fmt.Println("      --no-download <list> Archives that cannot be downloaded, comma-separated (* for all)")
+	fmt.Println("      --tls-cert <file>    TLS certificate file (reloaded when it changes)")
+	fmt.Println("      --tls-key <file>     TLS private key file")
+	fmt.Println("      --tls-self-signed <hosts>")
+	fmt.Println("                           Generate and keep a self-signed certificate for these names/IPs")
+	fmt.Println("      --tls-redirect-port <port>")
+	fmt.Println("                           Redirect plain HTTP on this port to HTTPS")
+	fmt.Println("      --htpasswd <file>    Require login against an htpasswd file (bcrypt hashes)")
+	fmt.Println("      --auth-tokens <list> Bearer tokens for API clients, comma-separated name:token")
+	fmt.Println("      --url-root <path>    Serve under a URL prefix, e.g. /library")
+	fmt.Println("      --trusted-proxies <list>")
+	fmt.Println("                           Proxy IPs/CIDRs (or unix for Unix sockets) whose X-Forwarded-* headers are honoured")
+	fmt.Println("      --metrics            Expose Prometheus metrics at /metrics")
+	fmt.Println("      --metrics-token <token>")
+	fmt.Println("                           Bearer token required to read /metrics")
+	fmt.Println("      --metrics-allow <list>")
+	fmt.Println("                           IPs/CIDRs allowed to read /metrics, comma-separated")
+	fmt.Println("      --log-level <level>  Log level: debug, info, warn or error (default: info)")
+	fmt.Println("      --log-format <fmt>   Log format: text or json (default: text)")
+	fmt.Println("      --access-log <file>  Write an access log to a rotating file (- for stdout)")
+	fmt.Println("      --access-log-format <fmt>")
+	fmt.Println("                           Access log format: combined or json (default: combined)")
+	fmt.Println("      --shutdown-timeout <d>")
+	fmt.Println("                           Time to wait for in-flight requests on shutdown (default: 30s)")
 	fmt.Println("  -h, --help               Show this help message")
 	fmt.Println("  -v, --version            Show version")
 	fmt.Println()
@@ -109,43 +481,482 @@ func printUsage() {
 	fmt.Println("  zimserver ./zim-files")
 	fmt.Println("  zimserver --host 0.0.0.0 --port 3000 ./zim-files")
 	fmt.Println("  zimserver file1.zim ./zim-dir")
+	fmt.Println("  zimserver linkcheck file1.zim")
+	fmt.Println("  zimserver --library library.xml")
+	fmt.Println("  zimserver library export -o library.xml ./zim-files")
+	fmt.Println("  zimserver --config zimserver.yaml")
+	fmt.Println("  zimserver --port 8443 --tls-self-signed zims.lan,192.168.1.10 ./zim-files")
+	fmt.Println()
+	fmt.Println("Settings are read from the configuration file, then ZIMSERVER_* environment")
+	fmt.Println("variables, then command-line flags. Send SIGHUP or edit the file to reload.")
 }
 
-func runServer(host, port string, paths []string) {
-	logSuccess("ZIMServer starting (version: %s)", version)
+func runServer(cfg *config.Config, configFile string, loadConfig func() (*config.Config, error)) {
+	logging.Success("ZIMServer starting", "version", version)
+
+	sessionSecret := make([]byte, 32)
+	if _, err := rand.Read(sessionSecret); err != nil {
+		slog.Error("Failed to generate session secret", "error", err)
+		os.Exit(1)
+	}
+
+	options, paths, err := serverOptions(cfg, sessionSecret)
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
 
-	server, err := web.NewServer(version)
+	accessLog, accessLogCloser, err := openAccessLog(cfg.Logging.Access)
 	if err != nil {
-		logError("Failed to create server: %v", err)
+		slog.Error("Failed to open access log", "error", err)
 		os.Exit(1)
 	}
+	options.AccessLog = accessLog
+
+	if options.Auth != nil {
+		slog.Info("Authentication enabled")
+	}
 
-	addr := host + ":" + port
-	logInfo("Listen on %shttp://%s:%s%s", colorCyan, host, port, colorReset)
+	server, err := web.NewServer(version, options)
+	if err != nil {
+		slog.Error("Failed to create server", "error", err)
+		os.Exit(1)
+	}
 
 	httpServer := &http.Server{
-		Addr:     addr,
 		Handler:  server,
-		ErrorLog: log.New(os.Stderr, "", log.LstdFlags),
+		ErrorLog: slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
 	}
 
-	go func() {
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logError("Server error: %v", err)
+	scheme := "http"
+	if cfg.TLSEnabled() {
+		scheme = "https"
+
+		tlsConfig, err := setupTLS(cfg)
+		if err != nil {
+			slog.Error("TLS setup failed", "error", err)
+			os.Exit(1)
+		}
+		httpServer.TLSConfig = tlsConfig
+	}
+
+	listeners, err := openListeners(cfg)
+	if err != nil {
+		slog.Error("Failed to listen", "error", err)
+		os.Exit(1)
+	}
+
+	root := utils.NormalizeURLRoot(cfg.URLRoot)
+	baseURL := root
+	for _, ln := range listeners {
+		if ln.Addr().Network() != "tcp" {
+			slog.Info("Listening", "address", ln.address)
+			continue
+		}
+
+		url := fmt.Sprintf("%s://%s%s", scheme, ln.address, root)
+		if baseURL == root {
+			baseURL = url
+		}
+		slog.Info("Listening", "url", url)
+	}
+
+	serverErr := make(chan error, len(listeners)+1)
+	useTLS := httpServer.TLSConfig != nil
+	for _, ln := range listeners {
+		go func(ln net.Listener) {
+			var err error
+			if useTLS {
+				err = httpServer.ServeTLS(ln, "", "")
+			} else {
+				err = httpServer.Serve(ln)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				serverErr <- err
+			}
+		}(ln.Listener)
+	}
+
+	var redirectServer *http.Server
+	if cfg.TLS.RedirectPort != "" {
+		var tlsHost, tlsPort string
+		for _, ln := range listeners {
+			if ln.Addr().Network() == "tcp" {
+				tlsHost, tlsPort, _ = net.SplitHostPort(ln.Addr().String())
+				break
+			}
+		}
+		if tlsPort == "" {
+			slog.Error("tls.redirect_port needs a TCP listener to redirect to")
 			os.Exit(1)
 		}
-	}()
+
+		redirectAddr := net.JoinHostPort(tlsHost, cfg.TLS.RedirectPort)
+		redirectServer = &http.Server{
+			Addr:     redirectAddr,
+			Handler:  httpsRedirect(tlsPort),
+			ErrorLog: slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+		}
+		slog.Info("Redirecting to HTTPS", "url", "http://"+redirectAddr)
+
+		go func() {
+			if err := redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				serverErr <- err
+			}
+		}()
+	}
+
+	watched := &watchedPaths{paths: paths}
+	stopWatcher := make(chan struct{})
 
 	go func() {
 		loadZimFiles(server, paths)
-		printLoadedArchives(server, host, port)
-		go watchFiles(server, paths)
+		server.SetStarted()
+		printLoadedArchives(server, baseURL)
+		go watchFiles(server, watched, stopWatcher)
+
+		notify(fmt.Sprintf("READY=1\nSTATUS=%d archive(s) loaded", len(server.ListArchives())))
 	}()
 
-	select {}
+	if interval := systemd.WatchdogInterval(); interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval / 2)
+			defer ticker.Stop()
+			for range ticker.C {
+				notify("WATCHDOG=1")
+			}
+		}()
+	}
+
+	reload := func(reason string) {
+		newCfg, err := loadConfig()
+		if err != nil {
+			slog.Error("Configuration reload failed, keeping current settings", "error", err)
+			return
+		}
+
+		newOptions, newPaths, err := serverOptions(newCfg, sessionSecret)
+		if err != nil {
+			slog.Error("Configuration reload failed, keeping current settings", "error", err)
+			return
+		}
+
+		if !reflect.DeepEqual(newCfg.ListenAddresses(), cfg.ListenAddresses()) || newCfg.Listen.SocketMode != cfg.Listen.SocketMode {
+			slog.Warn("Listen address changes require a restart", "addresses", strings.Join(newCfg.ListenAddresses(), ","))
+		}
+		if !reflect.DeepEqual(newCfg.TLS, cfg.TLS) {
+			slog.Warn("TLS settings changes require a restart")
+		}
+
+		oldAccessLogCloser := accessLogCloser
+		if newCfg.Logging.Access != cfg.Logging.Access {
+			newAccessLog, newCloser, err := openAccessLog(newCfg.Logging.Access)
+			if err != nil {
+				slog.Error("Configuration reload failed, keeping current settings", "error", err)
+				return
+			}
+			accessLog, accessLogCloser = newAccessLog, newCloser
+		} else {
+			oldAccessLogCloser = nil
+		}
+		newOptions.AccessLog = accessLog
+
+		setupLogging(newCfg)
+		server.Reload(newOptions)
+		watched.Set(newPaths)
+		cfg = newCfg
+
+		if oldAccessLogCloser != nil {
+			oldAccessLogCloser.Close()
+		}
+
+		logging.Success("Configuration reloaded", "reason", reason)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	term := make(chan os.Signal, 1)
+	signal.Notify(term, os.Interrupt, syscall.SIGTERM)
+
+	var configChanged <-chan time.Time
+	var configModTime time.Time
+	if configFile != "" {
+		if info, err := os.Stat(configFile); err == nil {
+			configModTime = info.ModTime()
+		}
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+		configChanged = ticker.C
+	}
+
+	exitCode := 0
+
+loop:
+	for {
+		select {
+		case sig := <-term:
+			slog.Info("Shutting down", "signal", sig.String())
+			break loop
+		case err := <-serverErr:
+			slog.Error("Server error", "error", err)
+			exitCode = 1
+			break loop
+		case <-hup:
+			reload("SIGHUP")
+		case <-configChanged:
+			info, err := os.Stat(configFile)
+			if err != nil || info.ModTime().Equal(configModTime) {
+				continue
+			}
+			configModTime = info.ModTime()
+			reload(filepath.Base(configFile) + " changed")
+		}
+	}
+
+	go func() {
+		<-term
+		slog.Error("Received second signal, exiting immediately")
+		os.Exit(1)
+	}()
+
+	notify("STOPPING=1")
+	close(stopWatcher)
+
+	if redirectServer != nil {
+		redirectServer.Close()
+	}
+
+	if !shutdownServer(httpServer, cfg.ShutdownTimeout) {
+		exitCode = 1
+	}
+
+	if accessLogCloser != nil {
+		accessLogCloser.Close()
+	}
+
+	if err := server.Close(); err != nil {
+		slog.Warn("Failed to close archives", "error", err)
+		exitCode = 1
+	}
+
+	if exitCode == 0 {
+		logging.Success("Shutdown complete")
+	} else {
+		slog.Error("Shutdown finished with errors")
+	}
+
+	os.Exit(exitCode)
+}
+
+type listener struct {
+	net.Listener
+	address string
+}
+
+func openListeners(cfg *config.Config) ([]listener, error) {
+	var listeners []listener
+	var activated []systemd.Listener
+	activatedLoaded := false
+	used := make(map[int]bool)
+
+	fail := func(err error) ([]listener, error) {
+		for _, ln := range listeners {
+			ln.Close()
+		}
+		for i, ln := range activated {
+			if !used[i] {
+				ln.Close()
+			}
+		}
+		return nil, err
+	}
+
+	for _, address := range cfg.ListenAddresses() {
+		if name, found := strings.CutPrefix(address, "systemd:"); found {
+			if !activatedLoaded {
+				var err error
+				if activated, err = systemd.Listeners(); err != nil {
+					return fail(err)
+				}
+				activatedLoaded = true
+			}
+
+			matched := false
+			for i, ln := range activated {
+				if used[i] || (name != "" && ln.Name != name) {
+					continue
+				}
+				used[i] = true
+				matched = true
+
+				label := ln.Addr().String()
+				if ln.Addr().Network() == "unix" {
+					label = "unix:" + label
+				}
+				listeners = append(listeners, listener{Listener: ln.Listener, address: label})
+			}
+			if !matched {
+				return fail(fmt.Errorf("%s: no matching socket passed by systemd", address))
+			}
+			continue
+		}
+
+		if path, found := strings.CutPrefix(address, "unix:"); found {
+			ln, err := listenUnix(path, cfg.SocketFileMode())
+			if err != nil {
+				return fail(err)
+			}
+			listeners = append(listeners, listener{Listener: ln, address: address})
+			continue
+		}
+
+		ln, err := net.Listen("tcp", address)
+		if err != nil {
+			return fail(err)
+		}
+		listeners = append(listeners, listener{Listener: ln, address: address})
+	}
+
+	for i, ln := range activated {
+		if !used[i] {
+			slog.Warn("Ignoring systemd socket not named in the listen addresses", "socket", ln.Name)
+			ln.Close()
+		}
+	}
+
+	return listeners, nil
+}
+
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is already in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	return ln, nil
 }
 
-func waitForStableFiles(zimFiles []string) []string {
+func setupTLS(cfg *config.Config) (*tls.Config, error) {
+	certFile, keyFile := cfg.TLSFiles()
+
+	if len(cfg.TLS.SelfSigned) > 0 {
+		generated, err := utils.EnsureSelfSignedCertificate(certFile, keyFile, cfg.TLS.SelfSigned)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate self-signed certificate: %w", err)
+		}
+		if generated {
+			logging.Success("Generated self-signed certificate", "hosts", strings.Join(cfg.TLS.SelfSigned, ","), "file", certFile)
+		}
+	}
+
+	reloader, err := utils.NewCertificateReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: reloader.GetCertificate,
+	}, nil
+}
+
+func httpsRedirect(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+func setupLogging(cfg *config.Config) {
+	level, _ := logging.ParseLevel(cfg.Logging.Level)
+	logging.Setup(os.Stderr, logging.Options{
+		Level:  level,
+		Format: cfg.Logging.Format,
+		Color:  cfg.Logging.Color,
+	})
+}
+
+func openAccessLog(cfg config.AccessLog) (*utils.AccessLog, io.Closer, error) {
+	if cfg.File == "" {
+		return nil, nil, nil
+	}
+
+	if cfg.File == "-" {
+		return &utils.AccessLog{Writer: os.Stdout, JSON: cfg.Format == "json"}, nil, nil
+	}
+
+	file, err := logging.OpenRotatingFile(cfg.File, cfg.MaxSizeMB*1024*1024, cfg.MaxBackups)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &utils.AccessLog{Writer: file, JSON: cfg.Format == "json"}, file, nil
+}
+
+func notify(state string) {
+	if err := systemd.Notify(state); err != nil {
+		slog.Warn("Failed to notify systemd", "error", err)
+	}
+}
+
+func shutdownServer(httpServer *http.Server, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		slog.Warn("In-flight requests did not finish in time, closing connections", "timeout", timeout, "error", err)
+		httpServer.Close()
+		return false
+	}
+
+	return true
+}
+
+type watchedPaths struct {
+	paths []string
+	mu    sync.Mutex
+}
+
+func (w *watchedPaths) Get() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.paths
+}
+
+func (w *watchedPaths) Set(paths []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.paths = paths
+}
+
+func waitForStableFiles(server *web.Server, zimFiles []string) []string {
 	var wg sync.WaitGroup
 	stableChan := make(chan string, len(zimFiles))
 
@@ -156,6 +967,7 @@ func waitForStableFiles(zimFiles []string) []string {
 			defer wg.Done()
 
 			baseName := filepath.Base(file)
+			server.SetArchiveState(file, services.StateStabilising, nil)
 
 			initialSize := int64(-1)
 			initialModTime := time.Time{}
@@ -164,7 +976,8 @@ func waitForStableFiles(zimFiles []string) []string {
 			for attempts < 5 {
 				info, err := os.Stat(file)
 				if err != nil {
-					logWarning("Cannot stat %s%s%s: %v", colorCyan, baseName, colorReset, err)
+					slog.Warn("Cannot stat file", "file", baseName, "error", err)
+					server.SetArchiveState(file, services.StateFailed, err)
 					return
 				}
 
@@ -189,7 +1002,8 @@ func waitForStableFiles(zimFiles []string) []string {
 			}
 
 			if attempts >= 5 {
-				logWarning("%s%s%s failed to stabilize after %d checks. Skipping load.", colorCyan, baseName, colorReset, attempts)
+				slog.Warn("File failed to stabilize, skipping load", "file", baseName, "checks", attempts)
+				server.SetArchiveState(file, services.StateFailed, errors.New("file kept changing"))
 			}
 		}(file)
 	}
@@ -209,14 +1023,18 @@ func loadZimFiles(server *web.Server, paths []string) {
 	rawFiles := collectZimFiles(paths)
 
 	if len(rawFiles) == 0 {
-		logError("No ZIM files found")
+		slog.Error("No ZIM files found")
 		return
 	}
 
-	zimFiles := waitForStableFiles(rawFiles)
+	for _, file := range rawFiles {
+		server.SetArchiveState(file, services.StateDiscovered, nil)
+	}
+
+	zimFiles := waitForStableFiles(server, rawFiles)
 
 	if len(zimFiles) == 0 {
-		logError("No stable ZIM files found to load")
+		slog.Error("No stable ZIM files found to load")
 		return
 	}
 
@@ -231,14 +1049,9 @@ func loadZimFiles(server *web.Server, paths []string) {
 			baseName := filepath.Base(file)
 
 			if err := server.LoadZIM(file); err != nil {
-				log.Printf("%s✗%s %sFailed to load %s%s%s: %s%v%s",
-					colorRed, colorReset,
-					colorRed,
-					colorCyan, baseName, colorReset,
-					colorRed, err, colorReset,
-				)
+				slog.Error("Failed to load ZIM", "file", baseName, "error", err)
 			} else {
-				logSuccess("Loaded ZIM: %s%s%s", colorCyan, baseName, colorReset)
+				logging.Success("Loaded ZIM", "file", baseName)
 			}
 		}(zimFile)
 	}
@@ -253,14 +1066,14 @@ func collectZimFiles(paths []string) []string {
 	for _, path := range paths {
 		info, err := os.Stat(path)
 		if err != nil {
-			logWarning("Cannot access %s%s%s: %v", colorCyan, path, colorReset, err)
+			slog.Warn("Cannot access path", "path", path, "error", err)
 			continue
 		}
 
 		if info.IsDir() {
 			entries, err := os.ReadDir(path)
 			if err != nil {
-				logWarning("Cannot read directory %s%s%s: %v", colorCyan, path, colorReset, err)
+				slog.Warn("Cannot read directory", "path", path, "error", err)
 				continue
 			}
 
@@ -290,15 +1103,23 @@ type fileState struct {
 	stable  bool
 }
 
-func watchFiles(server *web.Server, paths []string) {
+func watchFiles(server *web.Server, watched *watchedPaths, stop <-chan struct{}) {
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
 	fileStates := make(map[string]*fileState)
 	loadedFiles := make(map[string]bool)
 
-	initialFiles := collectZimFiles(paths)
+	loadedPaths := make(map[string]bool)
+	for _, archive := range server.ListArchives() {
+		loadedPaths[archive.Path] = true
+	}
+
+	initialFiles := collectZimFiles(watched.Get())
 	for _, f := range initialFiles {
+		if loadedPaths[f] {
+			loadedFiles[f] = true
+		}
 		if info, err := os.Stat(f); err == nil {
 			fileStates[f] = &fileState{
 				size:    info.Size(),
@@ -308,8 +1129,14 @@ func watchFiles(server *web.Server, paths []string) {
 		}
 	}
 
-	for range ticker.C {
-		currentFiles := collectZimFiles(paths)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		currentFiles := collectZimFiles(watched.Get())
 		currentMap := make(map[string]bool)
 
 		for _, f := range currentFiles {
@@ -326,7 +1153,9 @@ func watchFiles(server *web.Server, paths []string) {
 					modTime: info.ModTime(),
 					stable:  false,
 				}
-				logInfo("New file detected: %s%s%s", colorCyan, filepath.Base(f), colorReset)
+				slog.Info("New file detected", "file", filepath.Base(f))
+				metrics.WatcherEvent("added")
+				server.SetArchiveState(f, services.StateDiscovered, nil)
 				continue
 			}
 
@@ -334,28 +1163,30 @@ func watchFiles(server *web.Server, paths []string) {
 				if state.size == info.Size() && state.modTime.Equal(info.ModTime()) {
 					state.stable = true
 					if err := server.LoadZIM(f); err != nil {
-						logWarning("Failed to load %s%s%s: %v", colorCyan, filepath.Base(f), colorReset, err)
+						slog.Warn("Failed to load ZIM", "file", filepath.Base(f), "error", err)
 					} else {
 						loadedFiles[f] = true
-						logSuccess("Loaded ZIM: %s%s%s", colorCyan, filepath.Base(f), colorReset)
+						logging.Success("Loaded ZIM", "file", filepath.Base(f))
 					}
 				} else {
 					state.size = info.Size()
 					state.modTime = info.ModTime()
+					server.SetArchiveState(f, services.StateStabilising, nil)
 				}
 			}
 		}
 
 		for f := range loadedFiles {
 			if !currentMap[f] {
-				logWarning("File removed: %s%s%s", colorCyan, filepath.Base(f), colorReset)
+				slog.Warn("File removed", "file", filepath.Base(f))
+				metrics.WatcherEvent("removed")
 
 				if loadedFiles[f] {
 					baseName := filepath.Base(f)
 					name := strings.TrimSuffix(baseName, filepath.Ext(baseName))
 
 					if err := server.UnloadZIM(name); err != nil {
-						logWarning("Failed to unload %s%s%s: %v", colorCyan, name, colorReset, err)
+						slog.Warn("Failed to unload ZIM", "archive", name, "error", err)
 					}
 					delete(loadedFiles, f)
 				}
@@ -366,47 +1197,22 @@ func watchFiles(server *web.Server, paths []string) {
 		for f := range fileStates {
 			if !currentMap[f] {
 				delete(fileStates, f)
+				server.ForgetArchive(f)
 			}
 		}
 	}
 }
 
-func printLoadedArchives(server *web.Server, host, port string) {
+func printLoadedArchives(server *web.Server, baseURL string) {
 	archives := server.ListArchives()
 
-	count := len(archives)
-
-	archiveWord := "archive"
-	if count > 1 {
-		archiveWord = "archives"
+	if len(archives) == 0 {
+		slog.Info("No archives loaded")
+		return
 	}
 
-	if count > 0 {
-		logInfo("Loaded %d %s:", count, archiveWord)
-		for _, archive := range archives {
-			log.Printf("  %s-%s %s: %shttp://%s:%s/viewer/%s/%s", colorGreen, colorReset, archive.Metadata.Title, colorCyan, host, port, archive.Name, colorReset)
-		}
-	} else if count == 0 {
-		logInfo("No archives loaded.")
+	slog.Info("Archives loaded", "count", len(archives))
+	for _, archive := range archives {
+		slog.Info("Archive available", "title", archive.Metadata.Title, "url", baseURL+"/viewer/"+archive.Name+"/")
 	}
 }
-
-func logSuccess(format string, v ...interface{}) {
-	msg := fmt.Sprintf(format, v...)
-	log.Printf("%s✓%s %s", colorGreen, colorReset, msg)
-}
-
-func logInfo(format string, v ...interface{}) {
-	msg := fmt.Sprintf(format, v...)
-	log.Printf("%sℹ%s %s", colorBlue, colorReset, msg)
-}
-
-func logWarning(format string, v ...interface{}) {
-	msg := fmt.Sprintf(format, v...)
-	log.Printf("%s⚠%s %s", colorYellow, colorReset, msg)
-}
-
-func logError(format string, v ...interface{}) {
-	msg := fmt.Sprintf(format, v...)
-	log.Printf("%s✗%s %s", colorRed, colorReset, msg)
-}
@@ -0,0 +1,119 @@
+const urlRoot = document.querySelector('meta[name="zimserver-root"]')?.content || '';
+let galleryArchive;
+let galleryType = 'image';
+let galleryPage = 1;
+const galleryLimit = 60;
+
+function initGallery(archive, mediaType) {
+    galleryArchive = archive;
+    galleryType = mediaType || 'image';
+    loadGallery();
+}
+
+function selectType(mediaType) {
+    galleryType = mediaType;
+    galleryPage = 1;
+
+    const url = new URL(window.location.href);
+    url.searchParams.set('type', mediaType);
+    history.replaceState(null, '', url.pathname + url.search);
+
+    loadGallery();
+}
+
+function changePage(delta) {
+    galleryPage = Math.max(1, galleryPage + delta);
+    loadGallery();
+    window.scrollTo(0, 0);
+}
+
+function escapeHTML(str) {
+    return str.replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;').replace(/"/g, '&quot;');
+}
+
+function updateTabs(counts) {
+    document.querySelectorAll('#galleryTabs .btn').forEach(btn => {
+        if (btn.dataset.type === galleryType) {
+            btn.classList.add('primary');
+        } else {
+            btn.classList.remove('primary');
+        }
+    });
+
+    if (counts) {
+        Object.keys(counts).forEach(mediaType => {
+            const el = document.getElementById('count-' + mediaType);
+            if (el) el.textContent = '(' + counts[mediaType] + ')';
+        });
+    }
+}
+
+function renderItem(item) {
+    const viewerURL = urlRoot + '/viewer/' + galleryArchive + '/' + item.path;
+    const title = escapeHTML(item.title);
+
+    switch (galleryType) {
+        case 'image':
+            return `<a class="gallery-item" href="${escapeHTML(viewerURL)}" title="${title}">
+                <img src="${escapeHTML(item.url)}" alt="${title}" loading="lazy">
+                <span class="gallery-caption">${title}</span>
+            </a>`;
+        case 'audio':
+            return `<div class="gallery-item gallery-media">
+                <span class="gallery-caption">${title}</span>
+                <audio controls preload="none" src="${escapeHTML(item.url)}"></audio>
+            </div>`;
+        case 'video':
+            return `<div class="gallery-item gallery-media">
+                <video controls preload="none" src="${escapeHTML(item.url)}"></video>
+                <span class="gallery-caption">${title}</span>
+            </div>`;
+        default:
+            return `<a class="gallery-item gallery-media" href="${escapeHTML(viewerURL)}" title="${title}">
+                <span class="gallery-caption">${title}</span>
+                <span class="gallery-mime">${escapeHTML(item.mimeType)}</span>
+            </a>`;
+    }
+}
+
+function loadGallery() {
+    const status = document.getElementById('galleryStatus');
+    const list = document.getElementById('galleryList');
+    const pagination = document.getElementById('galleryPagination');
+
+    updateTabs(null);
+
+    fetch(urlRoot + '/api/' + galleryArchive + '/media?type=' + galleryType + '&page=' + galleryPage + '&limit=' + galleryLimit)
+        .then(res => res.json())
+        .then(data => {
+            if (data.status !== 'ready') {
+                status.textContent = 'Indexing media, please wait...';
+                pagination.classList.remove('active');
+                setTimeout(loadGallery, 2000);
+                return;
+            }
+
+            updateTabs(data.counts);
+
+            const plural = data.total === 1 ? 'item' : 'items';
+            status.textContent = data.total + ' ' + plural;
+
+            list.className = 'gallery gallery-' + galleryType;
+            list.innerHTML = data.items.map(renderItem).join('');
+
+            const pages = Math.max(1, Math.ceil(data.total / data.limit));
+            document.getElementById('pageInfo').textContent = data.page + ' / ' + pages;
+            document.getElementById('prevPage').disabled = data.page <= 1;
+            document.getElementById('nextPage').disabled = data.page >= pages;
+
+            if (pages > 1) {
+                pagination.classList.add('active');
+            } else {
+                pagination.classList.remove('active');
+            }
+        })
+        .catch(err => {
+            console.error('Media error:', err);
+            status.textContent = 'Failed to load media';
+        });
+}
@@ -1,3 +1,17 @@
+const placeholderIcon = 'data:image/svg+xml,%3Csvg xmlns=%27http://www.w3.org/2000/svg%27 viewBox=%270 0 24 24%27 fill=%27%23666%27%3E%3Cpath d=%27M18 2H6c-1.1 0-2 .9-2 2v16c0 1.1.9 2 2 2h12c1.1 0 2-.9 2-2V4c0-1.1-.9-2-2-2zM6 4h5v8l-2.5-1.5L6 12V4z%27/%3E%3C/svg%3E';
+
+function iconFallback(img) {
+    img.removeAttribute('srcset');
+
+    if (img.dataset.fallback) {
+        img.src = img.dataset.fallback;
+        delete img.dataset.fallback;
+    } else {
+        img.onerror = null;
+        img.src = placeholderIcon;
+    }
+}
+
 function toggleModal() {
     document.getElementById('infoModal').classList.toggle('active');
 }
@@ -0,0 +1,15 @@
+(function() {
+    if (window.parent === window) return;
+
+    function notify() {
+        try {
+            window.parent.postMessage({ type: 'zimserver:navigate' }, window.location.origin);
+        } catch (e) {
+            console.log('Cannot notify viewer:', e);
+        }
+    }
+
+    window.addEventListener('hashchange', notify);
+    window.addEventListener('popstate', notify);
+    notify();
+})();
@@ -1,6 +1,8 @@
+const urlRoot = document.querySelector('meta[name="zimserver-root"]')?.content || '';
 let searchTimeout;
 let archiveName;
 let lastSearchResults = '';
+let currentEntryPath = null;
 
 function init(archive) {
     archiveName = archive;
@@ -10,7 +12,7 @@ function init(archive) {
     const iframe = document.getElementById('contentFrame');
     const spinner = document.getElementById('spinner');
 
-    const pathPrefix = '/viewer/' + archiveName + '/';
+    const pathPrefix = urlRoot + '/viewer/' + archiveName + '/';
     if (window.location.pathname.startsWith(pathPrefix)) {
         if (window.location.search || window.location.hash) {
             const currentSrc = iframe.getAttribute('src');
@@ -76,7 +78,9 @@ function init(archive) {
             iframeWin.addEventListener('hashchange', updateBrowserURL);
             iframeWin.addEventListener('popstate', updateBrowserURL);
 
-            fixIframeURLs(iframeDoc);
+            if (!iframeDoc.documentElement.hasAttribute('data-zimserver-rewritten')) {
+                fixIframeURLs(iframeDoc);
+            }
 
             updateBrowserURL();
         } catch(e) {
@@ -120,20 +124,26 @@ function init(archive) {
         const search = window.location.search;
         const hash = window.location.hash;
         
-        if (path.startsWith('/viewer/' + archiveName + '/')) {
-            const entryPath = path.substring(('/viewer/' + archiveName + '/').length);
+        if (path.startsWith(urlRoot + '/viewer/' + archiveName + '/')) {
+            const entryPath = path.substring((urlRoot + '/viewer/' + archiveName + '/').length);
             showSpinner();
-            setIframeLocation('/content/' + archiveName + '/' + entryPath + search + hash);
-        } else if (path.startsWith('/catch')) {
+            setIframeLocation(urlRoot + '/content/' + archiveName + '/' + entryPath + search + hash);
+        } else if (path.startsWith(urlRoot + '/catch')) {
             const urlParams = new URLSearchParams(window.location.search);
             const externalUrl = urlParams.get('url');
             if (externalUrl) {
                 showSpinner();
-                setIframeLocation('/catch?url=' + encodeURIComponent(externalUrl));
+                setIframeLocation(urlRoot + '/catch?url=' + encodeURIComponent(externalUrl));
             }
         }
     });
 
+    window.addEventListener('message', function(e) {
+        if (e.origin === window.location.origin && e.data && e.data.type === 'zimserver:navigate') {
+            updateBrowserURL();
+        }
+    });
+
     setInterval(updateBrowserURL, 500);
 }
 
@@ -154,20 +164,24 @@ function updateBrowserURL() {
             const iframeLoc = iframe.contentWindow.location;
             const iframePath = iframeLoc.pathname;
 
-            const prefix = '/content/' + archiveName + '/';
+            const prefix = urlRoot + '/content/' + archiveName + '/';
             if (iframePath.startsWith(prefix)) {
                 const path = iframePath.substring(prefix.length);
-                const newUrl = '/viewer/' + archiveName + '/' + path + iframeLoc.search + iframeLoc.hash;
+                if (path !== currentEntryPath) {
+                    currentEntryPath = path;
+                    refreshPanels();
+                }
+                const newUrl = urlRoot + '/viewer/' + archiveName + '/' + path + iframeLoc.search + iframeLoc.hash;
                 const currentUrl = window.location.pathname + window.location.search + window.location.hash;
 
                 if (currentUrl !== newUrl) {
                     history.replaceState(null, '', newUrl);
                 }
-            } else if (iframePath.startsWith('/catch')) {
+            } else if (iframePath.startsWith(urlRoot + '/catch')) {
                 const urlParams = new URLSearchParams(iframeLoc.search);
                 const externalUrl = urlParams.get('url');
                 if (externalUrl) {
-                    const newUrl = '/catch?viewer=' + encodeURIComponent(archiveName) + '&url=' + encodeURIComponent(externalUrl);
+                    const newUrl = urlRoot + '/catch?viewer=' + encodeURIComponent(archiveName) + '&url=' + encodeURIComponent(externalUrl);
                     const currentUrl = window.location.pathname + window.location.search + window.location.hash;
                     if (currentUrl !== newUrl) {
                         history.replaceState(null, '', newUrl);
@@ -179,6 +193,155 @@ function updateBrowserURL() {
     }
 }
 
+function refreshPanels() {
+    const tocPanel = document.getElementById('tocPanel');
+    if (tocPanel && tocPanel.classList.contains('active')) {
+        loadTOC();
+    }
+
+    const backlinksPanel = document.getElementById('backlinksPanel');
+    if (backlinksPanel && backlinksPanel.classList.contains('active')) {
+        loadBacklinks();
+    }
+}
+
+function toggleTOC() {
+    const panel = document.getElementById('tocPanel');
+    if (!panel) return;
+
+    panel.classList.toggle('active');
+    if (panel.classList.contains('active')) {
+        loadTOC();
+    }
+}
+
+function loadTOC() {
+    const list = document.getElementById('tocList');
+    if (!list || currentEntryPath === null) return;
+
+    const requestedPath = currentEntryPath;
+    list.innerHTML = '<div class="side-panel-empty">Loading...</div>';
+
+    fetch(urlRoot + '/api/' + archiveName + '/toc?path=' + encodeURIComponent(decodeURIComponent(requestedPath)))
+        .then(res => {
+            if (!res.ok) throw new Error(res.statusText);
+            return res.json();
+        })
+        .then(data => {
+            if (requestedPath !== currentEntryPath) return;
+
+            if (data.headings.length === 0) {
+                list.innerHTML = '<div class="side-panel-empty">No headings in this article</div>';
+                return;
+            }
+
+            list.innerHTML = renderTOC(data.headings);
+        })
+        .catch(err => {
+            console.error('TOC error:', err);
+            list.innerHTML = '<div class="side-panel-empty">No table of contents available</div>';
+        });
+}
+
+function renderTOC(headings) {
+    return '<ul>' + headings.map(heading => {
+        const safeTitle = heading.title.replace(/</g, "&lt;").replace(/>/g, "&gt;");
+        const safeId = heading.id.replace(/\\/g, "\\\\").replace(/'/g, "\\'").replace(/"/g, "&quot;");
+        const toggle = heading.children.length > 0
+            ? '<button class="toc-toggle" onclick="toggleTOCSection(this)">▼</button>'
+            : '<span class="toc-toggle-placeholder"></span>';
+        const children = heading.children.length > 0 ? renderTOC(heading.children) : '';
+        return `<li><div class="toc-item">${toggle}<a href="#" onclick="scrollToHeading('${safeId}', ${heading.index}); return false;">${safeTitle}</a></div>${children}</li>`;
+    }).join('') + '</ul>';
+}
+
+function toggleTOCSection(button) {
+    const children = button.parentElement.nextElementSibling;
+    if (!children) return;
+
+    children.classList.toggle('collapsed');
+    button.classList.toggle('collapsed');
+}
+
+function scrollToHeading(id, index) {
+    const iframe = document.getElementById('contentFrame');
+
+    try {
+        const iframeDoc = iframe.contentDocument || iframe.contentWindow.document;
+        let target = id ? iframeDoc.getElementById(id) : null;
+        if (!target) {
+            target = iframeDoc.querySelectorAll('h1, h2, h3, h4, h5, h6')[index];
+        }
+        if (target) {
+            target.scrollIntoView({ behavior: 'smooth', block: 'start' });
+            if (id) {
+                iframe.contentWindow.history.replaceState(null, '', '#' + encodeURIComponent(id));
+            }
+        }
+    } catch (e) {
+        if (id) {
+            iframe.contentWindow.location.hash = id;
+        }
+    }
+
+    if (window.innerWidth <= 768) {
+        toggleTOC();
+    }
+}
+
+function toggleBacklinks() {
+    const panel = document.getElementById('backlinksPanel');
+    if (!panel) return;
+
+    panel.classList.toggle('active');
+    if (panel.classList.contains('active')) {
+        loadBacklinks();
+    }
+}
+
+function loadBacklinks() {
+    const list = document.getElementById('backlinksList');
+    if (!list || currentEntryPath === null) return;
+
+    const requestedPath = currentEntryPath;
+    list.innerHTML = '<div class="side-panel-empty">Loading...</div>';
+
+    let path;
+    try {
+        path = decodeURIComponent(requestedPath);
+    } catch (err) {
+        path = requestedPath;
+    }
+
+    fetch(urlRoot + '/api/' + archiveName + '/backlinks?path=' + encodeURIComponent(path))
+        .then(res => res.json())
+        .then(data => {
+            if (requestedPath !== currentEntryPath) return;
+
+            if (data.status !== 'ready') {
+                list.innerHTML = '<div class="side-panel-empty">Index is being built, please try again later</div>';
+                return;
+            }
+
+            if (data.results.length === 0) {
+                list.innerHTML = '<div class="side-panel-empty">No pages link here</div>';
+                return;
+            }
+
+            list.replaceChildren(...data.results.map(result => {
+                const item = document.createElement('div');
+                item.className = 'search-result-item';
+                item.textContent = result.title;
+                item.addEventListener('click', () => loadPage(result.path));
+                return item;
+            }));
+        })
+        .catch(err => {
+            console.error('Backlinks error:', err);
+            list.innerHTML = '<div class="side-panel-empty">Backlinks not available</div>';
+        });
+}
+
 function positionSearchResults() {
     const searchContainer = document.getElementById('searchContainer');
     const searchResults = document.getElementById('searchResults');
@@ -208,10 +371,10 @@ function fixIframeURLs(iframeDoc) {
                 if (hrefAttr.startsWith('http://') || hrefAttr.startsWith('https://')) {
                     e.preventDefault();
                     const encodedUrl = encodeURIComponent(hrefAttr);
-                    const newBrowserUrl = '/catch?viewer=' + encodeURIComponent(archiveName) + '&url=' + encodedUrl;
+                    const newBrowserUrl = urlRoot + '/catch?viewer=' + encodeURIComponent(archiveName) + '&url=' + encodedUrl;
                     history.pushState(null, '', newBrowserUrl);
                     showSpinner();
-                    setIframeLocation('/catch?url=' + encodedUrl);
+                    setIframeLocation(urlRoot + '/catch?url=' + encodedUrl);
                     return;
                 }
 
@@ -232,7 +395,7 @@ function fixIframeURLs(iframeDoc) {
                     const urlObj = new URL(hrefAttr, currentIframeUrl);
                     
                     if (urlObj.origin === window.location.origin) {
-                        const prefix = '/content/' + archiveName + '/';
+                        const prefix = urlRoot + '/content/' + archiveName + '/';
                         if (urlObj.pathname.startsWith(prefix)) {
                             let relativePath = urlObj.pathname.substring(prefix.length);
                             relativePath += urlObj.search + urlObj.hash;
@@ -242,10 +405,10 @@ function fixIframeURLs(iframeDoc) {
                         }
                     } else {
                         const encodedUrl = encodeURIComponent(urlObj.href);
-                        const newBrowserUrl = '/catch?viewer=' + encodeURIComponent(archiveName) + '&url=' + encodedUrl;
+                        const newBrowserUrl = urlRoot + '/catch?viewer=' + encodeURIComponent(archiveName) + '&url=' + encodedUrl;
                         history.pushState(null, '', newBrowserUrl);
                         showSpinner();
-                        setIframeLocation('/catch?url=' + encodedUrl);
+                        setIframeLocation(urlRoot + '/catch?url=' + encodedUrl);
                     }
                 } catch (err) {
                     console.error("Error parsing URL:", err);
@@ -361,7 +524,7 @@ function loadPage(path) {
         path = path.substring(1);
     }
 
-    const newUrl = '/viewer/' + archiveName + '/' + path;
+    const newUrl = urlRoot + '/viewer/' + archiveName + '/' + path;
     const currentUrl = window.location.pathname + window.location.search + window.location.hash;
 
     if (currentUrl !== newUrl) {
@@ -369,7 +532,7 @@ function loadPage(path) {
     }
 
     showSpinner();
-    setIframeLocation('/content/' + archiveName + '/' + path);
+    setIframeLocation(urlRoot + '/content/' + archiveName + '/' + path);
 
     const searchResults = document.getElementById('searchResults');
     if (searchResults) {
@@ -379,7 +542,7 @@ function loadPage(path) {
 
 function loadRandom() {
     showSpinner();
-    fetch('/api/' + archiveName + '/random')
+    fetch(urlRoot + '/api/' + archiveName + '/random')
         .then(res => res.json())
         .then(data => {
             if (data.path) {
@@ -414,7 +577,7 @@ function searchArticles(query) {
         if (clearBtn) clearBtn.classList.remove('visible');
         if (searchLoading) searchLoading.classList.add('active');
 
-        fetch('/api/' + archiveName + '/search?q=' + encodeURIComponent(query) + '&limit=-1')
+        fetch(urlRoot + '/api/' + archiveName + '/search?q=' + encodeURIComponent(query) + '&limit=-1')
             .then(res => res.json())
             .then(data => {
                 if (searchLoading) searchLoading.classList.remove('active');
@@ -0,0 +1,510 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaetanlhf/ZIMServer/internal/logging"
+	"gopkg.in/yaml.v3"
+)
+
+const envPrefix = "ZIMSERVER_"
+
+type Config struct {
+	Listen    Listen             `yaml:"listen"`
+	Paths     []string           `yaml:"paths"`
+	Library   string             `yaml:"library"`
+	Backlinks bool               `yaml:"backlinks"`
+	Cache     Cache              `yaml:"cache"`
+	Archives  map[string]Archive `yaml:"archives"`
+	Logging   Logging            `yaml:"logging"`
+	TLS       TLS                `yaml:"tls"`
+	Auth      Auth               `yaml:"auth"`
+	Access    []AccessRule       `yaml:"access"`
+	Metrics   Metrics            `yaml:"metrics"`
+
+	URLRoot        string   `yaml:"url_root"`
+	TrustedProxies []string `yaml:"trusted_proxies"`
+
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type Listen struct {
+	Host       string   `yaml:"host"`
+	Port       string   `yaml:"port"`
+	Addresses  []string `yaml:"addresses"`
+	SocketMode string   `yaml:"socket_mode"`
+}
+
+type Auth struct {
+	Htpasswd      string              `yaml:"htpasswd"`
+	Tokens        map[string]string   `yaml:"tokens"`
+	Groups        map[string][]string `yaml:"groups"`
+	SessionSecret string              `yaml:"session_secret"`
+	SessionTTL    time.Duration       `yaml:"session_ttl"`
+	Public        []string            `yaml:"public"`
+}
+
+type AccessRule struct {
+	Archives []string `yaml:"archives"`
+	Allow    []string `yaml:"allow"`
+}
+
+type Metrics struct {
+	Enabled bool     `yaml:"enabled"`
+	Path    string   `yaml:"path"`
+	Token   string   `yaml:"token"`
+	Allow   []string `yaml:"allow"`
+}
+
+type TLS struct {
+	Cert         string   `yaml:"cert"`
+	Key          string   `yaml:"key"`
+	SelfSigned   []string `yaml:"self_signed"`
+	RedirectPort string   `yaml:"redirect_port"`
+}
+
+type Cache struct {
+	MaxAge             time.Duration `yaml:"max_age"`
+	Compression        bool          `yaml:"compression"`
+	CompressionCacheMB int64         `yaml:"compression_cache_mb"`
+}
+
+type Archive struct {
+	Download *bool `yaml:"download"`
+}
+
+type Logging struct {
+	Requests bool      `yaml:"requests"`
+	Level    string    `yaml:"level"`
+	Format   string    `yaml:"format"`
+	Color    string    `yaml:"color"`
+	Access   AccessLog `yaml:"access"`
+}
+
+type AccessLog struct {
+	File       string `yaml:"file"`
+	Format     string `yaml:"format"`
+	MaxSizeMB  int64  `yaml:"max_size_mb"`
+	MaxBackups int    `yaml:"max_backups"`
+}
+
+func Default() *Config {
+	return &Config{
+		Listen: Listen{
+			Host:       "localhost",
+			Port:       "8080",
+			SocketMode: "0660",
+		},
+		Cache: Cache{
+			MaxAge:      24 * time.Hour,
+			Compression: true,
+		},
+		Archives: make(map[string]Archive),
+		Logging: Logging{
+			Requests: true,
+			Level:    "info",
+			Format:   "text",
+			Color:    "auto",
+			Access: AccessLog{
+				Format:     "combined",
+				MaxSizeMB:  100,
+				MaxBackups: 5,
+			},
+		},
+		Auth: Auth{
+			SessionTTL: 12 * time.Hour,
+			Public:     []string{"/assets/"},
+		},
+		Metrics: Metrics{
+			Path: "/metrics",
+		},
+		ShutdownTimeout: 30 * time.Second,
+	}
+}
+
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	baseDir := filepath.Dir(path)
+	for i, p := range c.Paths {
+		c.Paths[i] = resolvePath(baseDir, p)
+	}
+	if c.Library != "" {
+		c.Library = resolvePath(baseDir, c.Library)
+	}
+	c.TLS.Cert = resolvePath(baseDir, c.TLS.Cert)
+	c.TLS.Key = resolvePath(baseDir, c.TLS.Key)
+	c.Auth.Htpasswd = resolvePath(baseDir, c.Auth.Htpasswd)
+	if c.Logging.Access.File != "-" {
+		c.Logging.Access.File = resolvePath(baseDir, c.Logging.Access.File)
+	}
+
+	return nil
+}
+
+func (c *Config) ApplyEnv() error {
+	var errs []error
+
+	if value, ok := lookupEnv("HOST"); ok {
+		c.Listen.Host = value
+	}
+	if value, ok := lookupEnv("PORT"); ok {
+		c.Listen.Port = value
+	}
+	if value, ok := lookupEnv("LISTEN"); ok {
+		c.Listen.Addresses = SplitList(value)
+	}
+	if value, ok := lookupEnv("SOCKET_MODE"); ok {
+		c.Listen.SocketMode = value
+	}
+	if value, ok := lookupEnv("PATHS"); ok {
+		c.Paths = SplitList(value)
+	}
+	if value, ok := lookupEnv("LIBRARY"); ok {
+		c.Library = value
+	}
+	if value, ok := lookupEnv("BACKLINKS"); ok {
+		c.Backlinks, errs = parseBool("BACKLINKS", value, c.Backlinks, errs)
+	}
+	if value, ok := lookupEnv("CACHE_MAX_AGE"); ok {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%sCACHE_MAX_AGE: %w", envPrefix, err))
+		} else {
+			c.Cache.MaxAge = d
+		}
+	}
+	if value, ok := lookupEnv("COMPRESSION"); ok {
+		c.Cache.Compression, errs = parseBool("COMPRESSION", value, c.Cache.Compression, errs)
+	}
+	if value, ok := lookupEnv("COMPRESSION_CACHE"); ok {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%sCOMPRESSION_CACHE: %w", envPrefix, err))
+		} else {
+			c.Cache.CompressionCacheMB = n
+		}
+	}
+	if value, ok := lookupEnv("NO_DOWNLOAD"); ok {
+		c.DisableDownloads(SplitList(value))
+	}
+	if value, ok := lookupEnv("LOG_REQUESTS"); ok {
+		c.Logging.Requests, errs = parseBool("LOG_REQUESTS", value, c.Logging.Requests, errs)
+	}
+	if value, ok := lookupEnv("LOG_LEVEL"); ok {
+		c.Logging.Level = value
+	}
+	if value, ok := lookupEnv("LOG_FORMAT"); ok {
+		c.Logging.Format = value
+	}
+	if value, ok := lookupEnv("LOG_COLOR"); ok {
+		c.Logging.Color = value
+	}
+	if value, ok := lookupEnv("ACCESS_LOG"); ok {
+		c.Logging.Access.File = value
+	}
+	if value, ok := lookupEnv("ACCESS_LOG_FORMAT"); ok {
+		c.Logging.Access.Format = value
+	}
+	if value, ok := lookupEnv("TLS_CERT"); ok {
+		c.TLS.Cert = value
+	}
+	if value, ok := lookupEnv("TLS_KEY"); ok {
+		c.TLS.Key = value
+	}
+	if value, ok := lookupEnv("TLS_SELF_SIGNED"); ok {
+		c.TLS.SelfSigned = SplitList(value)
+	}
+	if value, ok := lookupEnv("TLS_REDIRECT_PORT"); ok {
+		c.TLS.RedirectPort = value
+	}
+	if value, ok := lookupEnv("AUTH_HTPASSWD"); ok {
+		c.Auth.Htpasswd = value
+	}
+	if value, ok := lookupEnv("AUTH_TOKENS"); ok {
+		tokens, err := ParseTokens(SplitList(value))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%sAUTH_TOKENS: %w", envPrefix, err))
+		} else {
+			c.Auth.Tokens = tokens
+		}
+	}
+	if value, ok := lookupEnv("AUTH_SESSION_SECRET"); ok {
+		c.Auth.SessionSecret = value
+	}
+	if value, ok := lookupEnv("AUTH_PUBLIC"); ok {
+		c.Auth.Public = SplitList(value)
+	}
+	if value, ok := lookupEnv("METRICS"); ok {
+		c.Metrics.Enabled, errs = parseBool("METRICS", value, c.Metrics.Enabled, errs)
+	}
+	if value, ok := lookupEnv("METRICS_PATH"); ok {
+		c.Metrics.Path = value
+	}
+	if value, ok := lookupEnv("METRICS_TOKEN"); ok {
+		c.Metrics.Token = value
+	}
+	if value, ok := lookupEnv("METRICS_ALLOW"); ok {
+		c.Metrics.Allow = SplitList(value)
+	}
+	if value, ok := lookupEnv("URL_ROOT"); ok {
+		c.URLRoot = value
+	}
+	if value, ok := lookupEnv("TRUSTED_PROXIES"); ok {
+		c.TrustedProxies = SplitList(value)
+	}
+	if value, ok := lookupEnv("SHUTDOWN_TIMEOUT"); ok {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%sSHUTDOWN_TIMEOUT: %w", envPrefix, err))
+		} else {
+			c.ShutdownTimeout = d
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Config) DisableDownloads(names []string) {
+	if c.Archives == nil {
+		c.Archives = make(map[string]Archive)
+	}
+
+	disabled := false
+	for _, name := range names {
+		archive := c.Archives[name]
+		archive.Download = &disabled
+		c.Archives[name] = archive
+	}
+}
+
+func (c *Config) NoDownload() []string {
+	names := make([]string, 0)
+	for name, archive := range c.Archives {
+		if archive.Download != nil && !*archive.Download {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (c *Config) Addr() string {
+	return c.Listen.Host + ":" + c.Listen.Port
+}
+
+func (c *Config) ListenAddresses() []string {
+	if len(c.Listen.Addresses) > 0 {
+		return c.Listen.Addresses
+	}
+	return []string{c.Addr()}
+}
+
+func (c *Config) SocketFileMode() os.FileMode {
+	mode, _ := strconv.ParseUint(c.Listen.SocketMode, 8, 32)
+	return os.FileMode(mode)
+}
+
+func (c *Config) TLSEnabled() bool {
+	return c.TLS.Cert != "" || len(c.TLS.SelfSigned) > 0
+}
+
+func (c *Config) TLSFiles() (string, string) {
+	if c.TLS.Cert != "" || len(c.TLS.SelfSigned) == 0 {
+		return c.TLS.Cert, c.TLS.Key
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	dir = filepath.Join(dir, "zimserver")
+
+	return filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+}
+
+func (c *Config) AuthEnabled() bool {
+	return c.Auth.Htpasswd != "" || len(c.Auth.Tokens) > 0
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Listen.Host == "" {
+		errs = append(errs, errors.New("listen.host must not be empty"))
+	}
+	if port, err := strconv.Atoi(c.Listen.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("listen.port %q is not a valid port", c.Listen.Port))
+	}
+	for _, address := range c.Listen.Addresses {
+		if path, found := strings.CutPrefix(address, "unix:"); found {
+			if path == "" {
+				errs = append(errs, fmt.Errorf("listen address %q needs a socket path", address))
+			}
+			continue
+		}
+		if strings.HasPrefix(address, "systemd:") {
+			continue
+		}
+		if _, port, err := net.SplitHostPort(address); err != nil {
+			errs = append(errs, fmt.Errorf("listen address %q: %w", address, err))
+		} else if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			errs = append(errs, fmt.Errorf("listen address %q has an invalid port", address))
+		}
+	}
+	if mode, err := strconv.ParseUint(c.Listen.SocketMode, 8, 32); err != nil || mode > 0777 {
+		errs = append(errs, fmt.Errorf("listen.socket_mode %q is not an octal file mode", c.Listen.SocketMode))
+	}
+	if _, err := logging.ParseLevel(c.Logging.Level); err != nil {
+		errs = append(errs, fmt.Errorf("logging.level: %w", err))
+	}
+	if c.Logging.Format != "text" && c.Logging.Format != "json" {
+		errs = append(errs, fmt.Errorf("logging.format %q must be text or json", c.Logging.Format))
+	}
+	if c.Logging.Color != "auto" && c.Logging.Color != "always" && c.Logging.Color != "never" {
+		errs = append(errs, fmt.Errorf("logging.color %q must be auto, always or never", c.Logging.Color))
+	}
+	if c.Logging.Access.Format != "combined" && c.Logging.Access.Format != "json" {
+		errs = append(errs, fmt.Errorf("logging.access.format %q must be combined or json", c.Logging.Access.Format))
+	}
+	if c.Logging.Access.MaxSizeMB < 0 || c.Logging.Access.MaxBackups < 0 {
+		errs = append(errs, errors.New("logging.access.max_size_mb and max_backups must not be negative"))
+	}
+	if c.Cache.MaxAge < 0 {
+		errs = append(errs, errors.New("cache.max_age must not be negative"))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("shutdown_timeout must be positive"))
+	}
+	if c.Cache.CompressionCacheMB < 0 {
+		errs = append(errs, errors.New("cache.compression_cache_mb must not be negative"))
+	}
+	if len(c.Paths) == 0 && c.Library == "" {
+		errs = append(errs, errors.New("no ZIM files or directories specified"))
+	}
+	if (c.TLS.Cert == "") != (c.TLS.Key == "") {
+		errs = append(errs, errors.New("tls.cert and tls.key must be set together"))
+	}
+	if c.TLS.Cert != "" && len(c.TLS.SelfSigned) == 0 {
+		for _, file := range []string{c.TLS.Cert, c.TLS.Key} {
+			if _, err := os.Stat(file); err != nil {
+				errs = append(errs, fmt.Errorf("tls: %w", err))
+			}
+		}
+	}
+	if c.TLS.RedirectPort != "" {
+		if !c.TLSEnabled() {
+			errs = append(errs, errors.New("tls.redirect_port requires TLS to be enabled"))
+		} else if port, err := strconv.Atoi(c.TLS.RedirectPort); err != nil || port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("tls.redirect_port %q is not a valid port", c.TLS.RedirectPort))
+		} else {
+			for _, address := range c.ListenAddresses() {
+				if strings.HasPrefix(address, "unix:") || strings.HasPrefix(address, "systemd:") {
+					continue
+				}
+				if _, port, err := net.SplitHostPort(address); err == nil && port == c.TLS.RedirectPort {
+					errs = append(errs, fmt.Errorf("tls.redirect_port must differ from the port of %s", address))
+				}
+			}
+		}
+	}
+	if c.Auth.Htpasswd != "" {
+		if _, err := os.Stat(c.Auth.Htpasswd); err != nil {
+			errs = append(errs, fmt.Errorf("auth.htpasswd: %w", err))
+		}
+	}
+	for name, token := range c.Auth.Tokens {
+		if len(token) < 16 {
+			errs = append(errs, fmt.Errorf("auth.tokens.%s must be at least 16 characters", name))
+		}
+	}
+	if c.AuthEnabled() && c.Auth.SessionTTL <= 0 {
+		errs = append(errs, errors.New("auth.session_ttl must be positive"))
+	}
+	for _, route := range c.Auth.Public {
+		if !strings.HasPrefix(route, "/") {
+			errs = append(errs, fmt.Errorf("auth.public route %q must start with /", route))
+		}
+	}
+	for i, rule := range c.Access {
+		if len(rule.Archives) == 0 || len(rule.Allow) == 0 {
+			errs = append(errs, fmt.Errorf("access rule %d needs both archives and allow", i+1))
+		}
+	}
+	if c.Metrics.Enabled {
+		if !strings.HasPrefix(c.Metrics.Path, "/") || c.Metrics.Path == "/" {
+			errs = append(errs, fmt.Errorf("metrics.path %q must start with / and not be the root", c.Metrics.Path))
+		}
+		if c.Metrics.Token != "" && len(c.Metrics.Token) < 16 {
+			errs = append(errs, errors.New("metrics.token must be at least 16 characters"))
+		}
+	}
+	if strings.ContainsAny(c.URLRoot, "?#\"'<>\\ ") {
+		errs = append(errs, fmt.Errorf("url_root %q contains invalid characters", c.URLRoot))
+	}
+	if c.Library != "" {
+		if _, err := os.Stat(c.Library); err != nil {
+			errs = append(errs, fmt.Errorf("library: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func SplitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func ParseTokens(items []string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, item := range items {
+		name, token, found := strings.Cut(item, ":")
+		if !found || name == "" || token == "" {
+			return nil, fmt.Errorf("token %q must be name:token", item)
+		}
+		tokens[name] = token
+	}
+	return tokens, nil
+}
+
+func lookupEnv(name string) (string, bool) {
+	return os.LookupEnv(envPrefix + name)
+}
+
+func parseBool(name, value string, current bool, errs []error) (bool, []error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return current, append(errs, fmt.Errorf("%s%s: %w", envPrefix, name, err))
+	}
+	return b, errs
+}
+
+func resolvePath(baseDir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
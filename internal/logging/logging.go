@@ -0,0 +1,184 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const LevelSuccess = slog.Level(2)
+
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorBlue   = "\033[34m"
+	colorCyan   = "\033[36m"
+	colorGray   = "\033[90m"
+)
+
+type Options struct {
+	Level  slog.Level
+	Format string
+	Color  string
+}
+
+var level slog.LevelVar
+
+func Setup(w io.Writer, options Options) {
+	level.Set(options.Level)
+
+	var handler slog.Handler
+	handlerOptions := &slog.HandlerOptions{Level: &level, ReplaceAttr: replaceLevel}
+
+	switch {
+	case options.Format == "json":
+		handler = slog.NewJSONHandler(w, handlerOptions)
+	case UseColor(w, options.Color):
+		handler = &consoleHandler{w: w, mu: &sync.Mutex{}}
+	default:
+		handler = slog.NewTextHandler(w, handlerOptions)
+	}
+
+	slog.SetDefault(slog.New(handler))
+}
+
+func ParseLevel(name string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return l, fmt.Errorf("unknown log level %q", name)
+	}
+	return l, nil
+}
+
+func Success(msg string, args ...any) {
+	slog.Log(context.Background(), LevelSuccess, msg, args...)
+}
+
+func replaceLevel(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 {
+		if l, ok := a.Value.Any().(slog.Level); ok && l == LevelSuccess {
+			a.Value = slog.StringValue(slog.LevelInfo.String())
+		}
+	}
+	return a
+}
+
+func UseColor(w io.Writer, mode string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+type consoleHandler struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	attrs  string
+	prefix string
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= level.Level()
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+
+	b.WriteString(r.Time.Format("2006/01/02 15:04:05"))
+	b.WriteByte(' ')
+	b.WriteString(levelSymbol(r.Level))
+	b.WriteByte(' ')
+	b.WriteString(r.Message)
+	b.WriteString(h.attrs)
+
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, h.prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	for _, a := range attrs {
+		writeAttr(&b, h.prefix, a)
+	}
+
+	clone := *h
+	clone.attrs = b.String()
+	return &clone
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+func levelSymbol(l slog.Level) string {
+	switch {
+	case l >= slog.LevelError:
+		return colorRed + "✗" + colorReset
+	case l >= slog.LevelWarn:
+		return colorYellow + "⚠" + colorReset
+	case l >= LevelSuccess:
+		return colorGreen + "✓" + colorReset
+	case l >= slog.LevelInfo:
+		return colorBlue + "ℹ" + colorReset
+	default:
+		return colorGray + "·" + colorReset
+	}
+}
+
+func writeAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			writeAttr(b, prefix+a.Key+".", ga)
+		}
+		return
+	}
+
+	value := a.Value.String()
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		value = strconv.Quote(value)
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(colorGray + prefix + a.Key + "=" + colorReset)
+	b.WriteString(colorCyan + value + colorReset)
+}
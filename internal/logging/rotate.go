@@ -0,0 +1,129 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	closed     bool
+	mu         sync.Mutex
+}
+
+func OpenRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	if f.file != nil && f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		f.rotate()
+	}
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *RotatingFile) rotate() {
+	if err := f.file.Close(); err != nil {
+		slog.Warn("Failed to close log file for rotation", "path", f.path, "error", err)
+	}
+	f.file = nil
+
+	if err := os.Remove(backupName(f.path, f.maxBackups)); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove old log file", "path", backupName(f.path, f.maxBackups), "error", err)
+	}
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(backupName(f.path, i), backupName(f.path, i+1)); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to rotate log file", "path", backupName(f.path, i), "error", err)
+		}
+	}
+
+	var err error
+	if f.maxBackups > 0 {
+		err = os.Rename(f.path, backupName(f.path, 1))
+	} else {
+		err = os.Remove(f.path)
+	}
+	if err != nil {
+		slog.Warn("Failed to rotate log file, appending to it instead", "path", f.path, "error", err)
+	}
+
+	if err := f.open(); err != nil {
+		slog.Error("Failed to reopen log file", "path", f.path, "error", err)
+		return
+	}
+	if err != nil {
+		f.size = 0
+	}
+}
+
+func backupName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
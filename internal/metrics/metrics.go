@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	zimreader "github.com/gaetanlhf/ZIMServer/internal/zim/reader"
+)
+
+const namespace = "zimserver"
+
+var (
+	registry = prometheus.NewRegistry()
+
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, archive and status code.",
+	}, []string{"route", "archive", "code"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and archive.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "archive"})
+
+	responseBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_response_bytes_total",
+		Help:      "Response body bytes served by route and archive.",
+	}, []string{"route", "archive"})
+
+	searchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "search_duration_seconds",
+		Help:      "Search and suggestion query latency by archive.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"archive", "kind"})
+
+	watcherEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "watcher_events_total",
+		Help:      "ZIM files added or removed while watching the configured paths.",
+	}, []string{"event"})
+
+	loadFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "archive_load_failures_total",
+		Help:      "ZIM files that failed to load.",
+	})
+
+	decompressionsDesc = prometheus.NewDesc(namespace+"_cluster_decompressions_total",
+		"Clusters decompressed by compression type.", []string{"compression"}, nil)
+	decompressionSecondsDesc = prometheus.NewDesc(namespace+"_cluster_decompression_seconds_total",
+		"Time spent decompressing clusters by compression type.", []string{"compression"}, nil)
+	archivesDesc = prometheus.NewDesc(namespace+"_archives_loaded",
+		"Archives currently loaded.", nil, nil)
+	cacheHitsDesc = prometheus.NewDesc(namespace+"_compression_cache_hits_total",
+		"Compressed responses served from the cache.", nil, nil)
+	cacheMissesDesc = prometheus.NewDesc(namespace+"_compression_cache_misses_total",
+		"Compressed responses that had to be compressed again.", nil, nil)
+	cacheBytesDesc = prometheus.NewDesc(namespace+"_compression_cache_bytes",
+		"Size of the compressed response cache.", nil, nil)
+)
+
+type Sources struct {
+	Archives         func() int
+	CompressionCache func() (hits, misses uint64, size int64)
+}
+
+type sourceCollector struct {
+	mu      sync.RWMutex
+	sources Sources
+}
+
+var (
+	sourcesCollector = &sourceCollector{}
+	handler          = promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requests,
+		requestDuration,
+		responseBytes,
+		searchDuration,
+		watcherEvents,
+		loadFailures,
+		sourcesCollector,
+	)
+}
+
+func Handler() http.Handler {
+	return handler
+}
+
+func SetSources(sources Sources) {
+	sourcesCollector.mu.Lock()
+	sourcesCollector.sources = sources
+	sourcesCollector.mu.Unlock()
+}
+
+func ObserveRequest(route, archive string, code int, bytes int64, duration time.Duration) {
+	requests.WithLabelValues(route, archive, strconv.Itoa(code)).Inc()
+	requestDuration.WithLabelValues(route, archive).Observe(duration.Seconds())
+	responseBytes.WithLabelValues(route, archive).Add(float64(bytes))
+}
+
+func ObserveSearch(archive, kind string, duration time.Duration) {
+	searchDuration.WithLabelValues(archive, kind).Observe(duration.Seconds())
+}
+
+func WatcherEvent(event string) {
+	watcherEvents.WithLabelValues(event).Inc()
+}
+
+func LoadFailed() {
+	loadFailures.Inc()
+}
+
+func (c *sourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- decompressionsDesc
+	ch <- decompressionSecondsDesc
+	ch <- archivesDesc
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheBytesDesc
+}
+
+func (c *sourceCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, stat := range zimreader.DecompressionStats() {
+		compression := stat.Compression.String()
+		ch <- prometheus.MustNewConstMetric(decompressionsDesc, prometheus.CounterValue, float64(stat.Count), compression)
+		ch <- prometheus.MustNewConstMetric(decompressionSecondsDesc, prometheus.CounterValue, stat.Duration.Seconds(), compression)
+	}
+
+	c.mu.RLock()
+	sources := c.sources
+	c.mu.RUnlock()
+
+	if sources.Archives != nil {
+		ch <- prometheus.MustNewConstMetric(archivesDesc, prometheus.GaugeValue, float64(sources.Archives()))
+	}
+
+	var hits, misses uint64
+	var size int64
+	if sources.CompressionCache != nil {
+		hits, misses, size = sources.CompressionCache()
+	}
+	ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(hits))
+	ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(misses))
+	ch <- prometheus.MustNewConstMetric(cacheBytesDesc, prometheus.GaugeValue, float64(size))
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gaetanlhf/ZIMServer/internal/web/services"
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
+)
+
+type AccessCacheHandler struct {
+	Access *services.AccessPolicy
+	Next   http.Handler
+}
+
+func (h *AccessCacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/assets/") {
+		h.Next.ServeHTTP(w, r)
+		return
+	}
+
+	archive := utils.RequestArchive(r.URL.Path, r)
+	if archive != "" && !h.Access.Restricts(archive) {
+		h.Next.ServeHTTP(w, r)
+		return
+	}
+
+	h.Next.ServeHTTP(&privateCacheWriter{ResponseWriter: w}, r)
+}
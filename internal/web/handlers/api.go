@@ -1,19 +1,30 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/gaetanlhf/ZIMServer/internal/metrics"
 	"github.com/gaetanlhf/ZIMServer/internal/web/services"
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
+	zimreader "github.com/gaetanlhf/ZIMServer/internal/zim/reader"
 )
 
 type APIHandler struct {
-	ArchiveService *services.ArchiveService
-	SearchService  *services.SearchService
+	ArchiveService   *services.ArchiveService
+	SearchService    *services.SearchService
+	MediaService     *services.MediaService
+	BacklinkService  *services.BacklinkService
+	LinkCheckService *services.LinkCheckService
+	CacheMaxAge      time.Duration
 }
 
 type APISearchResponse struct {
@@ -32,8 +43,149 @@ type APIRandomResponse struct {
 	Path  string `json:"path"`
 }
 
+type APIMediaResponse struct {
+	Status string                     `json:"status"`
+	Type   services.MediaType         `json:"type"`
+	Counts map[services.MediaType]int `json:"counts,omitempty"`
+	Total  int                        `json:"total"`
+	Page   int                        `json:"page"`
+	Limit  int                        `json:"limit"`
+	Items  []APIMediaItem             `json:"items"`
+}
+
+type APIBacklinksResponse struct {
+	Status  string            `json:"status"`
+	Path    string            `json:"path"`
+	Results []APISearchResult `json:"results"`
+	Count   int               `json:"count"`
+}
+
+type APILinkCheckResponse struct {
+	Status string               `json:"status"`
+	Report *services.LinkReport `json:"report,omitempty"`
+}
+
+type APITOCResponse struct {
+	Path     string         `json:"path"`
+	Title    string         `json:"title"`
+	Headings []*APITOCEntry `json:"headings"`
+}
+
+type APITOCEntry struct {
+	Level    int            `json:"level"`
+	ID       string         `json:"id"`
+	Title    string         `json:"title"`
+	Index    int            `json:"index"`
+	Children []*APITOCEntry `json:"children"`
+}
+
+type APIEntryResponse struct {
+	Namespace       string        `json:"namespace"`
+	Path            string        `json:"path"`
+	Title           string        `json:"title"`
+	Index           uint32        `json:"index"`
+	IsRedirect      bool          `json:"isRedirect"`
+	Revision        uint32        `json:"revision"`
+	RedirectChain   []APIEntryHop `json:"redirectChain,omitempty"`
+	RedirectError   string        `json:"redirectError,omitempty"`
+	MimeType        string        `json:"mimeType,omitempty"`
+	ClusterNumber   *uint32       `json:"clusterNumber,omitempty"`
+	BlobNumber      *uint32       `json:"blobNumber,omitempty"`
+	Compression     string        `json:"compression,omitempty"`
+	ClusterExtended *bool         `json:"clusterExtended,omitempty"`
+	ClusterSize     *uint64       `json:"clusterSize,omitempty"`
+	BlobSize        *int          `json:"blobSize,omitempty"`
+}
+
+type APIEntryHop struct {
+	Namespace  string `json:"namespace"`
+	Path       string `json:"path"`
+	Title      string `json:"title"`
+	IsRedirect bool   `json:"isRedirect"`
+}
+
+type APIMetadataResponse struct {
+	Archive  string             `json:"archive"`
+	Metadata []APIMetadataEntry `json:"metadata"`
+	Count    int                `json:"count"`
+}
+
+type APIMetadataEntry struct {
+	Key      string `json:"key"`
+	MimeType string `json:"mimeType"`
+	Size     int    `json:"size"`
+	Value    string `json:"value,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+type APIArchivesResponse struct {
+	Archives []APIArchive `json:"archives"`
+	Total    int          `json:"total"`
+	Page     int          `json:"page"`
+	Limit    int          `json:"limit"`
+}
+
+type APIStatusResponse struct {
+	Ready    bool               `json:"ready"`
+	Archives []APIArchiveStatus `json:"archives"`
+}
+
+type APIArchiveStatus struct {
+	Name  string    `json:"name"`
+	State string    `json:"state"`
+	Error string    `json:"error,omitempty"`
+	Since time.Time `json:"since"`
+}
+
+type APIArchive struct {
+	Name            string            `json:"name"`
+	UUID            string            `json:"uuid"`
+	Title           string            `json:"title"`
+	Description     string            `json:"description"`
+	LongDescription string            `json:"longDescription,omitempty"`
+	Language        string            `json:"language"`
+	LanguageCode    string            `json:"languageCode"`
+	Creator         string            `json:"creator"`
+	Publisher       string            `json:"publisher"`
+	Date            string            `json:"date"`
+	Category        string            `json:"category"`
+	Tags            []string          `json:"tags"`
+	Flavour         string            `json:"flavour,omitempty"`
+	Size            int64             `json:"size"`
+	EntryCount      uint32            `json:"entryCount"`
+	ArticleCount    int               `json:"articleCount"`
+	MediaCount      int               `json:"mediaCount"`
+	Search          APISearchCapacity `json:"search"`
+	State           string            `json:"state"`
+	URL             string            `json:"url"`
+	Illustration    string            `json:"illustration"`
+}
+
+type APISearchCapacity struct {
+	Title    bool `json:"title"`
+	Random   bool `json:"random"`
+	FullText bool `json:"fullText"`
+}
+
+type APIMediaItem struct {
+	Title    string `json:"title"`
+	Path     string `json:"path"`
+	MimeType string `json:"mimeType"`
+	URL      string `json:"url"`
+}
+
 func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/")
+
+	switch path {
+	case "archives":
+		h.handleArchives(w, r)
+		return
+	case "status":
+		h.handleStatus(w, r)
+		return
+	}
+
 	parts := strings.SplitN(path, "/", 2)
 
 	if len(parts) < 2 {
@@ -44,7 +196,7 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	archiveName := parts[0]
 	action := parts[1]
 
-	archive, exists := h.ArchiveService.GetArchive(archiveName)
+	archive, exists := h.ArchiveService.GetArchive(r.Context(), archiveName)
 	if !exists {
 		http.NotFound(w, r)
 		return
@@ -55,6 +207,20 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.handleSearch(w, r, archive)
 	case "random":
 		h.handleRandom(w, r, archive)
+	case "media":
+		h.handleMedia(w, r, archive)
+	case "backlinks":
+		h.handleBacklinks(w, r, archive)
+	case "linkcheck":
+		h.handleLinkCheck(w, r, archive)
+	case "toc":
+		h.handleTOC(w, r, archive)
+	case "entry":
+		h.handleEntry(w, r, archive)
+	case "metadata":
+		h.handleMetadata(w, r, archive)
+	case "illustration":
+		h.handleIllustration(w, r, archive)
 	default:
 		http.NotFound(w, r)
 	}
@@ -83,9 +249,11 @@ func (h *APIHandler) handleSearch(w http.ResponseWriter, r *http.Request, archiv
 		}
 	}
 
+	start := time.Now()
 	results, err := archive.IndexMgr.Search(query, limit)
+	metrics.ObserveSearch(archive.Name, "search", time.Since(start))
 	if err != nil {
-		log.Printf("Search error: %v", err)
+		slog.Error("Search error", "error", err)
 		http.Error(w, fmt.Sprintf("Search failed: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -109,14 +277,14 @@ func (h *APIHandler) handleSearch(w http.ResponseWriter, r *http.Request, archiv
 
 func (h *APIHandler) handleRandom(w http.ResponseWriter, r *http.Request, archive *services.Archive) {
 	if archive.IndexMgr == nil {
-		log.Printf("Random failed: IndexMgr is nil for archive %s", archive.Name)
+		slog.Warn("Random failed: no title index", "archive", archive.Name)
 		http.Error(w, "Random not available for this archive", http.StatusServiceUnavailable)
 		return
 	}
 
 	entry, err := archive.IndexMgr.GetRandomArticle()
 	if err != nil {
-		log.Printf("Random error for archive %s: %v", archive.Name, err)
+		slog.Error("Random error", "archive", archive.Name, "error", err)
 		http.Error(w, fmt.Sprintf("Random failed: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -129,3 +297,518 @@ func (h *APIHandler) handleRandom(w http.ResponseWriter, r *http.Request, archiv
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+func (h *APIHandler) handleMedia(w http.ResponseWriter, r *http.Request, archive *services.Archive) {
+	mediaType := services.MediaImage
+	if typeStr := r.URL.Query().Get("type"); typeStr != "" {
+		parsed, ok := services.ParseMediaType(typeStr)
+		if !ok {
+			http.Error(w, "Invalid media type", http.StatusBadRequest)
+			return
+		}
+		mediaType = parsed
+	}
+
+	page := 1
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 500 {
+			limit = l
+		}
+	}
+
+	response := APIMediaResponse{
+		Status: "building",
+		Type:   mediaType,
+		Page:   page,
+		Limit:  limit,
+		Items:  make([]APIMediaItem, 0),
+	}
+
+	idx := h.MediaService.GetIndex(archive)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if !idx.Ready() {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	offset := -1
+	if page-1 <= math.MaxInt/limit {
+		offset = (page - 1) * limit
+	}
+
+	items, total := idx.List(archive.Reader, mediaType, offset, limit)
+
+	response.Status = "ready"
+	response.Counts = idx.Counts()
+	response.Total = total
+
+	for _, item := range items {
+		response.Items = append(response.Items, APIMediaItem{
+			Title:    item.Title,
+			Path:     item.Path,
+			MimeType: item.MimeType,
+			URL:      utils.RootURL(r, fmt.Sprintf("/content/%s/%s", archive.Name, item.Path)),
+		})
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *APIHandler) handleBacklinks(w http.ResponseWriter, r *http.Request, archive *services.Archive) {
+	idx, exists := h.BacklinkService.GetIndex(archive.UUID)
+	if !exists {
+		http.Error(w, "Backlinks not available for this archive", http.StatusServiceUnavailable)
+		return
+	}
+
+	entryPath := r.URL.Query().Get("path")
+	if entryPath == "" {
+		http.Error(w, "Missing query parameter 'path'", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := archive.FS.GetEntry(entryPath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	response := APIBacklinksResponse{
+		Status:  "building",
+		Path:    entryPath,
+		Results: make([]APISearchResult, 0),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if !idx.Ready() {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	target, err := archive.Reader.GetEntryIndexByPath(string(entry.GetNamespace()) + entry.GetPath())
+	if err == nil {
+		target, err = archive.Reader.ResolveRedirectIndex(target)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to resolve entry: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response.Status = "ready"
+	for _, source := range idx.Sources(target) {
+		sourceEntry, err := archive.Reader.GetEntryByIndex(source)
+		if err != nil {
+			continue
+		}
+
+		response.Results = append(response.Results, APISearchResult{
+			Title: sourceEntry.GetTitle(),
+			Path:  sourceEntry.GetPath(),
+		})
+	}
+	response.Count = len(response.Results)
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *APIHandler) handleLinkCheck(w http.ResponseWriter, r *http.Request, archive *services.Archive) {
+	if _, ok := services.PrincipalFromContext(r.Context()); !ok {
+		http.Error(w, "Link check requires authentication", http.StatusForbidden)
+		return
+	}
+
+	refresh := r.URL.Query().Get("refresh") == "1"
+
+	report, running, retryAfter := h.LinkCheckService.GetReport(archive, refresh)
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+		http.Error(w, "Link check is busy, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	response := APILinkCheckResponse{
+		Status: "ready",
+		Report: report,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if running {
+		response.Status = "running"
+		if report == nil {
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *APIHandler) handleTOC(w http.ResponseWriter, r *http.Request, archive *services.Archive) {
+	entryPath := r.URL.Query().Get("path")
+	if entryPath == "" {
+		http.Error(w, "Missing query parameter 'path'", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := archive.FS.GetEntry(entryPath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	resolvedEntry, err := archive.Reader.ResolveRedirect(entry)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to resolve entry: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	mimeType, _ := archive.Reader.GetMimeType(resolvedEntry)
+	if !strings.HasPrefix(mimeType, "text/html") {
+		http.Error(w, "Entry is not an HTML article", http.StatusUnprocessableEntity)
+		return
+	}
+
+	content, err := archive.Reader.GetContent(resolvedEntry)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read entry: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := APITOCResponse{
+		Path:     resolvedEntry.GetPath(),
+		Title:    resolvedEntry.GetTitle(),
+		Headings: make([]*APITOCEntry, 0),
+	}
+
+	stack := make([]*APITOCEntry, 0)
+	for _, heading := range utils.ExtractHeadings(content) {
+		tocEntry := &APITOCEntry{
+			Level:    heading.Level,
+			ID:       heading.ID,
+			Title:    heading.Title,
+			Index:    heading.Index,
+			Children: make([]*APITOCEntry, 0),
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].Level >= heading.Level {
+			stack = stack[:len(stack)-1]
+		}
+
+		if len(stack) == 0 {
+			response.Headings = append(response.Headings, tocEntry)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, tocEntry)
+		}
+
+		stack = append(stack, tocEntry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *APIHandler) handleEntry(w http.ResponseWriter, r *http.Request, archive *services.Archive) {
+	entryPath := r.URL.Query().Get("path")
+	if entryPath == "" {
+		http.Error(w, "Missing query parameter 'path'", http.StatusBadRequest)
+		return
+	}
+
+	var entry zimreader.DirectoryEntry
+	var err error
+
+	if namespace := r.URL.Query().Get("namespace"); namespace != "" {
+		if len(namespace) != 1 {
+			http.Error(w, "Invalid namespace", http.StatusBadRequest)
+			return
+		}
+		entry, err = archive.Reader.GetEntryByURL(namespace[0], entryPath)
+	} else {
+		entry, err = archive.FS.GetEntry(entryPath)
+	}
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	index, err := archive.Reader.GetEntryIndexByPath(string(entry.GetNamespace()) + entry.GetPath())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to locate entry: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := APIEntryResponse{
+		Namespace:  string(entry.GetNamespace()),
+		Path:       entry.GetPath(),
+		Title:      entry.GetTitle(),
+		Index:      index,
+		IsRedirect: entry.IsRedirect(),
+	}
+
+	switch e := entry.(type) {
+	case *zimreader.ContentEntry:
+		response.Revision = e.Revision
+	case *zimreader.RedirectEntry:
+		response.Revision = e.Revision
+	}
+
+	chain, err := archive.Reader.GetRedirectChain(entry)
+	if entry.IsRedirect() {
+		for _, hop := range chain[1:] {
+			response.RedirectChain = append(response.RedirectChain, APIEntryHop{
+				Namespace:  string(hop.GetNamespace()),
+				Path:       hop.GetPath(),
+				Title:      hop.GetTitle(),
+				IsRedirect: hop.IsRedirect(),
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err != nil {
+		response.RedirectError = err.Error()
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	contentEntry, ok := chain[len(chain)-1].(*zimreader.ContentEntry)
+	if !ok {
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response.MimeType, _ = archive.Reader.GetMimeType(contentEntry)
+	response.ClusterNumber = &contentEntry.ClusterNumber
+	response.BlobNumber = &contentEntry.BlobNumber
+
+	if cluster, err := archive.Reader.GetCluster(contentEntry.ClusterNumber); err == nil {
+		clusterSize := cluster.Size()
+		response.Compression = cluster.Compression.String()
+		response.ClusterExtended = &cluster.Extended
+		response.ClusterSize = &clusterSize
+	}
+
+	if content, err := archive.Reader.GetContent(contentEntry); err == nil {
+		blobSize := len(content)
+		response.BlobSize = &blobSize
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *APIHandler) handleMetadata(w http.ResponseWriter, r *http.Request, archive *services.Archive) {
+	if key := r.URL.Query().Get("key"); key != "" {
+		entry, err := archive.Reader.GetEntryByURL(zimreader.NamespaceMetadata, key)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		content, err := archive.Reader.GetContent(entry)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read metadata: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		mimeType, _ := archive.Reader.GetMimeType(entry)
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+
+		w.Header().Set("Content-Type", mimeType)
+		w.Write(content)
+		return
+	}
+
+	metadata, err := h.ArchiveService.ListMetadata(archive)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list metadata: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := APIMetadataResponse{
+		Archive:  archive.Name,
+		Metadata: make([]APIMetadataEntry, 0, len(metadata)),
+		Count:    len(metadata),
+	}
+
+	for _, item := range metadata {
+		entry := APIMetadataEntry{
+			Key:      item.Key,
+			MimeType: item.MimeType,
+			Size:     item.Size,
+		}
+
+		if item.IsText {
+			entry.Value = item.Value
+		} else {
+			entry.URL = utils.RootURL(r, fmt.Sprintf("/api/%s/metadata?key=%s", archive.Name, url.QueryEscape(item.Key)))
+		}
+
+		response.Metadata = append(response.Metadata, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *APIHandler) handleIllustration(w http.ResponseWriter, r *http.Request, archive *services.Archive) {
+	size := 48
+	if sizeStr := r.URL.Query().Get("size"); sizeStr != "" {
+		if s, err := strconv.Atoi(sizeStr); err == nil && s > 0 {
+			size = s
+		}
+	}
+
+	scale := 1
+	if scaleStr := r.URL.Query().Get("scale"); scaleStr != "" {
+		if s, err := strconv.Atoi(scaleStr); err == nil && s > 0 {
+			scale = s
+		}
+	}
+
+	illustration, found := h.ArchiveService.FindIllustration(archive, size, scale)
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+
+	content, err := archive.Reader.GetContent(illustration.Entry)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read illustration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	mimeType, _ := archive.Reader.GetMimeType(illustration.Entry)
+	if mimeType == "" {
+		mimeType = "image/png"
+	}
+
+	w.Header().Set("Content-Type", mimeType)
+	utils.SetContentCacheHeaders(w, utils.ContentETag(archive.UUID, illustration.Entry, ""), h.CacheMaxAge, false)
+
+	http.ServeContent(w, r, "illustration", timeZero, bytes.NewReader(content))
+}
+
+func (h *APIHandler) handleArchives(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	page := 1
+	if pageStr := q.Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	limit := 50
+	if limitStr := q.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			if l == -1 {
+				limit = -1
+			} else if l > 0 {
+				limit = l
+			}
+		}
+	}
+
+	query := services.ArchiveQuery{
+		Language: q.Get("lang"),
+		Category: q.Get("category"),
+		Tag:      q.Get("tag"),
+		Query:    q.Get("q"),
+		Sort:     q.Get("sort"),
+		Desc:     q.Get("order") == "desc",
+	}
+
+	if limit > 0 {
+		query.Offset = -1
+		if page-1 <= math.MaxInt/limit {
+			query.Offset = (page - 1) * limit
+		}
+		query.Limit = limit
+	}
+
+	archives, total := h.ArchiveService.QueryArchives(r.Context(), query)
+
+	response := APIArchivesResponse{
+		Archives: make([]APIArchive, 0, len(archives)),
+		Total:    total,
+		Page:     page,
+		Limit:    limit,
+	}
+
+	for _, archive := range archives {
+		response.Archives = append(response.Archives, newAPIArchive(archive, h.ArchiveService.State(archive.Name), utils.URLRoot(r.Context())))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *APIHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	states := h.ArchiveService.States(r.Context())
+
+	response := APIStatusResponse{
+		Ready:    h.ArchiveService.Ready(),
+		Archives: make([]APIArchiveStatus, 0, len(states)),
+	}
+
+	for _, status := range states {
+		response.Archives = append(response.Archives, APIArchiveStatus{
+			Name:  status.Name,
+			State: string(status.State),
+			Error: status.Error,
+			Since: status.Since,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(response)
+}
+
+func newAPIArchive(archive *services.Archive, state services.ArchiveState, root string) APIArchive {
+	metadata := archive.Metadata
+
+	return APIArchive{
+		Name:            archive.Name,
+		UUID:            archive.UUID,
+		Title:           metadata.Title,
+		Description:     metadata.Description,
+		LongDescription: metadata.LongDescription,
+		Language:        metadata.Language,
+		LanguageCode:    metadata.LanguageCode,
+		Creator:         metadata.Creator,
+		Publisher:       metadata.Publisher,
+		Date:            metadata.Date,
+		Category:        metadata.Category,
+		Tags:            archive.Tags(),
+		Flavour:         metadata.Flavour,
+		Size:            archive.Size,
+		EntryCount:      metadata.EntryCount,
+		ArticleCount:    archive.ArticleCount,
+		MediaCount:      archive.MediaCount,
+		Search: APISearchCapacity{
+			Title:    archive.IndexMgr != nil,
+			Random:   archive.IndexMgr != nil,
+			FullText: archive.HasFullText,
+		},
+		State:        string(state),
+		URL:          fmt.Sprintf("%s/viewer/%s/", root, archive.Name),
+		Illustration: fmt.Sprintf("%s/api/%s/illustration?size=48", root, archive.Name),
+	}
+}
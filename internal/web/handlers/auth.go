@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gaetanlhf/ZIMServer/internal/web/services"
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
+)
+
+type AuthHandler struct {
+	AuthService *services.AuthService
+	Templates   TemplateRenderer
+	Next        http.Handler
+}
+
+type LoginData struct {
+	Next  string
+	Error string
+}
+
+func (h *AuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/login":
+		h.handleLogin(w, r)
+		return
+	case "/logout":
+		h.handleLogout(w, r)
+		return
+	}
+
+	if principal, ok := h.AuthService.Authenticate(r); ok {
+		utils.SetRequestUser(r.Context(), principal.Name)
+		r = r.WithContext(services.ContextWithPrincipal(r.Context(), principal))
+		h.Next.ServeHTTP(&privateCacheWriter{ResponseWriter: w}, r)
+		return
+	}
+
+	if h.AuthService.IsPublic(r.URL.Path) {
+		h.Next.ServeHTTP(w, r)
+		return
+	}
+
+	if r.Method == http.MethodGet && h.AuthService.HasPasswords() && strings.Contains(r.Header.Get("Accept"), "text/html") {
+		http.Redirect(w, r, utils.RootURL(r, "/login?next="+url.QueryEscape(r.URL.RequestURI())), http.StatusFound)
+		return
+	}
+
+	if h.AuthService.HasPasswords() {
+		w.Header().Set("WWW-Authenticate", `Basic realm="ZIMServer", charset="UTF-8"`)
+	} else {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="ZIMServer"`)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	http.Error(w, "Authentication required", http.StatusUnauthorized)
+}
+
+func (h *AuthHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if !h.AuthService.HasPasswords() {
+		http.NotFound(w, r)
+		return
+	}
+
+	data := LoginData{Next: safeRedirect(r.FormValue("next"))}
+
+	if r.Method == http.MethodPost {
+		username := r.PostFormValue("username")
+		if h.AuthService.CheckPassword(username, r.PostFormValue("password")) {
+			cookie := h.AuthService.NewSession(username)
+			cookie.Path = utils.RootURL(r, "/")
+			cookie.Secure = utils.IsSecure(r)
+			http.SetCookie(w, cookie)
+
+			slog.Info("Login", "user", username)
+			http.Redirect(w, r, utils.RootURL(r, data.Next), http.StatusSeeOther)
+			return
+		}
+
+		slog.Warn("Login failed", "user", username, "remote", r.RemoteAddr)
+		data.Error = "Invalid username or password"
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := h.Templates.Render(w, r, "login", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		slog.Error("Template error", "error", err)
+	}
+}
+
+func (h *AuthHandler) handleLogout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     services.SessionCookie,
+		Value:    "",
+		Path:     utils.RootURL(r, "/"),
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	target := "/"
+	if h.AuthService.HasPasswords() {
+		target = "/login"
+	}
+
+	http.Redirect(w, r, utils.RootURL(r, target), http.StatusSeeOther)
+}
+
+func safeRedirect(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+type privateCacheWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (pw *privateCacheWriter) WriteHeader(code int) {
+	if !pw.wroteHeader {
+		pw.wroteHeader = true
+		header := pw.Header()
+		if cacheControl := header.Get("Cache-Control"); strings.HasPrefix(cacheControl, "public") {
+			header.Set("Cache-Control", "private"+strings.TrimPrefix(cacheControl, "public"))
+		}
+	}
+	pw.ResponseWriter.WriteHeader(code)
+}
+
+func (pw *privateCacheWriter) Write(p []byte) (int, error) {
+	if !pw.wroteHeader {
+		pw.WriteHeader(http.StatusOK)
+	}
+	return pw.ResponseWriter.Write(p)
+}
+
+func (pw *privateCacheWriter) Flush() {
+	if flusher, ok := pw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,484 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaetanlhf/ZIMServer/internal/web/services"
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
+)
+
+const (
+	catalogRoot = "/catalog/v2"
+
+	opdsNavigationType  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	opdsAcquisitionType = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	opdsEntryType       = "application/atom+xml;type=entry;profile=opds-catalog"
+	openSearchType      = "application/opensearchdescription+xml"
+)
+
+type CatalogHandler struct {
+	ArchiveService *services.ArchiveService
+	CacheMaxAge    time.Duration
+}
+
+type OPDSFeed struct {
+	XMLName      xml.Name    `xml:"feed"`
+	Xmlns        string      `xml:"xmlns,attr"`
+	XmlnsDC      string      `xml:"xmlns:dc,attr"`
+	XmlnsOPDS    string      `xml:"xmlns:opds,attr"`
+	XmlnsSearch  string      `xml:"xmlns:opensearch,attr,omitempty"`
+	XmlnsThr     string      `xml:"xmlns:thr,attr,omitempty"`
+	ID           string      `xml:"id"`
+	Links        []OPDSLink  `xml:"link"`
+	Title        string      `xml:"title"`
+	Updated      string      `xml:"updated"`
+	TotalResults *int        `xml:"opensearch:totalResults,omitempty"`
+	StartIndex   *int        `xml:"opensearch:startIndex,omitempty"`
+	ItemsPerPage *int        `xml:"opensearch:itemsPerPage,omitempty"`
+	Entries      []OPDSEntry `xml:"entry"`
+}
+
+type OPDSEntry struct {
+	XMLName      xml.Name    `xml:"entry"`
+	Xmlns        string      `xml:"xmlns,attr,omitempty"`
+	XmlnsDC      string      `xml:"xmlns:dc,attr,omitempty"`
+	XmlnsOPDS    string      `xml:"xmlns:opds,attr,omitempty"`
+	ID           string      `xml:"id"`
+	Title        string      `xml:"title"`
+	Updated      string      `xml:"updated"`
+	Summary      string      `xml:"summary,omitempty"`
+	Content      string      `xml:"content,omitempty"`
+	Language     string      `xml:"language,omitempty"`
+	Name         string      `xml:"name,omitempty"`
+	Flavour      string      `xml:"flavour,omitempty"`
+	Category     string      `xml:"category,omitempty"`
+	Tags         string      `xml:"tags,omitempty"`
+	ArticleCount *int        `xml:"articleCount,omitempty"`
+	MediaCount   *int        `xml:"mediaCount,omitempty"`
+	Author       *OPDSPerson `xml:"author,omitempty"`
+	Publisher    *OPDSPerson `xml:"publisher,omitempty"`
+	Issued       string      `xml:"dc:issued,omitempty"`
+	Links        []OPDSLink  `xml:"link"`
+}
+
+type OPDSPerson struct {
+	Name string `xml:"name"`
+}
+
+type OPDSLink struct {
+	Rel    string `xml:"rel,attr,omitempty"`
+	Href   string `xml:"href,attr"`
+	Type   string `xml:"type,attr,omitempty"`
+	Title  string `xml:"title,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+	Count  int    `xml:"thr:count,attr,omitempty"`
+}
+
+type OpenSearchDescription struct {
+	XMLName     xml.Name      `xml:"OpenSearchDescription"`
+	Xmlns       string        `xml:"xmlns,attr"`
+	ShortName   string        `xml:"ShortName"`
+	Description string        `xml:"Description"`
+	InputEnc    string        `xml:"InputEncoding"`
+	OutputEnc   string        `xml:"OutputEncoding"`
+	URL         OpenSearchURL `xml:"Url"`
+}
+
+type OpenSearchURL struct {
+	Type     string `xml:"type,attr"`
+	Template string `xml:"template,attr"`
+}
+
+func (h *CatalogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, catalogRoot)
+	path = strings.TrimSuffix(path, "/")
+
+	switch {
+	case path == "" || path == "/root.xml":
+		h.handleRoot(w, r)
+	case path == "/searchdescription.xml":
+		h.handleSearchDescription(w, r)
+	case path == "/entries":
+		h.handleEntries(w, r, false)
+	case path == "/partial_entries":
+		h.handleEntries(w, r, true)
+	case strings.HasPrefix(path, "/entry/"):
+		h.handleEntry(w, r, strings.TrimPrefix(path, "/entry/"))
+	case path == "/categories":
+		h.handleCategories(w, r)
+	case path == "/languages":
+		h.handleLanguages(w, r)
+	case strings.HasPrefix(path, "/illustration/"):
+		h.handleIllustration(w, r, strings.TrimPrefix(path, "/illustration/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *CatalogHandler) newFeed(r *http.Request, id, title, selfHref, selfType string) OPDSFeed {
+	return OPDSFeed{
+		Xmlns:     "http://www.w3.org/2005/Atom",
+		XmlnsDC:   "http://purl.org/dc/terms/",
+		XmlnsOPDS: "https://specs.opds.io/opds-1.2",
+		ID:        id,
+		Title:     title,
+		Updated:   h.lastUpdated(r),
+		Links: []OPDSLink{
+			{Rel: "self", Href: selfHref, Type: selfType},
+			{Rel: "start", Href: utils.RootURL(r, catalogRoot+"/root.xml"), Type: opdsNavigationType},
+			{Rel: "search", Href: utils.RootURL(r, catalogRoot+"/searchdescription.xml"), Type: openSearchType},
+		},
+		Entries: make([]OPDSEntry, 0),
+	}
+}
+
+func (h *CatalogHandler) handleRoot(w http.ResponseWriter, r *http.Request) {
+	feed := h.newFeed(r, catalogID("root"), "ZIMServer library", utils.RootURL(r, catalogRoot+"/root.xml"), opdsNavigationType)
+	updated := feed.Updated
+
+	navigation := []struct {
+		id      string
+		title   string
+		content string
+		href    string
+		kind    string
+	}{
+		{"entries", "All entries", "All entries from this catalog.", utils.RootURL(r, catalogRoot+"/entries"), opdsAcquisitionType},
+		{"partial_entries", "All entries (partial)", "All entries from this catalog in partial format.", utils.RootURL(r, catalogRoot+"/partial_entries"), opdsAcquisitionType},
+		{"categories", "List of categories", "List of all categories in this catalog.", utils.RootURL(r, catalogRoot+"/categories"), opdsNavigationType},
+		{"languages", "List of languages", "List of all languages in this catalog.", utils.RootURL(r, catalogRoot+"/languages"), opdsNavigationType},
+	}
+
+	for _, nav := range navigation {
+		feed.Entries = append(feed.Entries, OPDSEntry{
+			ID:      catalogID(nav.id),
+			Title:   nav.title,
+			Updated: updated,
+			Content: nav.content,
+			Links:   []OPDSLink{{Rel: "subsection", Href: nav.href, Type: nav.kind}},
+		})
+	}
+
+	writeXML(w, feed, opdsNavigationType)
+}
+
+func (h *CatalogHandler) handleSearchDescription(w http.ResponseWriter, r *http.Request) {
+	description := OpenSearchDescription{
+		Xmlns:       "http://a9.com/-/spec/opensearch/1.1/",
+		ShortName:   "ZIMServer catalog search",
+		Description: "Search the ZIMServer catalog.",
+		InputEnc:    "UTF-8",
+		OutputEnc:   "UTF-8",
+		URL: OpenSearchURL{
+			Type:     opdsAcquisitionType,
+			Template: utils.RootURL(r, catalogRoot+"/entries?q={searchTerms?}&lang={language?}&name={k:name?}&tag={k:tag?}&category={k:category?}&count={count?}&start={startIndex?}"),
+		},
+	}
+
+	writeXML(w, description, openSearchType)
+}
+
+func (h *CatalogHandler) handleEntries(w http.ResponseWriter, r *http.Request, partial bool) {
+	q := r.URL.Query()
+
+	start := 0
+	if startStr := q.Get("start"); startStr != "" {
+		if s, err := strconv.Atoi(startStr); err == nil && s >= 0 {
+			start = s
+		}
+	}
+
+	count := 10
+	if countStr := q.Get("count"); countStr != "" {
+		if c, err := strconv.Atoi(countStr); err == nil {
+			if c < 0 {
+				count = 0
+			} else {
+				count = c
+			}
+		}
+	}
+
+	query := services.ArchiveQuery{
+		Name:     q.Get("name"),
+		Language: q.Get("lang"),
+		Category: q.Get("category"),
+		Tag:      q.Get("tag"),
+		Query:    q.Get("q"),
+		Offset:   start,
+		Limit:    count,
+	}
+
+	archives, total := h.ArchiveService.QueryArchives(r.Context(), query)
+
+	selfHref := utils.RootURL(r, catalogRoot+"/entries")
+	if partial {
+		selfHref = utils.RootURL(r, catalogRoot+"/partial_entries")
+	}
+	if r.URL.RawQuery != "" {
+		selfHref += "?" + r.URL.RawQuery
+	}
+
+	feed := h.newFeed(r, catalogID(selfHref), "Filtered zims ("+r.URL.RawQuery+")", selfHref, opdsAcquisitionType)
+	if r.URL.RawQuery == "" {
+		feed.Title = "All zims"
+	}
+
+	itemsPerPage := len(archives)
+	feed.XmlnsSearch = "http://a9.com/-/spec/opensearch/1.1/"
+	feed.TotalResults = &total
+	feed.StartIndex = &start
+	feed.ItemsPerPage = &itemsPerPage
+
+	for _, archive := range archives {
+		if partial {
+			feed.Entries = append(feed.Entries, newPartialOPDSEntry(archive, utils.URLRoot(r.Context())))
+		} else {
+			feed.Entries = append(feed.Entries, newOPDSEntry(archive, utils.URLRoot(r.Context())))
+		}
+	}
+
+	writeXML(w, feed, opdsAcquisitionType)
+}
+
+func (h *CatalogHandler) handleEntry(w http.ResponseWriter, r *http.Request, uuid string) {
+	archive, exists := h.ArchiveService.GetArchiveByUUID(r.Context(), uuid)
+	if !exists {
+		http.NotFound(w, r)
+		return
+	}
+
+	entry := newOPDSEntry(archive, utils.URLRoot(r.Context()))
+	entry.Xmlns = "http://www.w3.org/2005/Atom"
+	entry.XmlnsDC = "http://purl.org/dc/terms/"
+	entry.XmlnsOPDS = "https://specs.opds.io/opds-1.2"
+
+	writeXML(w, entry, opdsEntryType)
+}
+
+func (h *CatalogHandler) handleCategories(w http.ResponseWriter, r *http.Request) {
+	feed := h.newFeed(r, catalogID("categories"), "List of categories", utils.RootURL(r, catalogRoot+"/categories"), opdsNavigationType)
+
+	counts := make(map[string]int)
+	for _, archive := range h.ArchiveService.ListArchives(r.Context()) {
+		if category := catalogCategory(archive); category != "" {
+			counts[category]++
+		}
+	}
+
+	categories := make([]string, 0, len(counts))
+	for category := range counts {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	for _, category := range categories {
+		feed.Entries = append(feed.Entries, OPDSEntry{
+			ID:      catalogID("category:" + category),
+			Title:   category,
+			Updated: feed.Updated,
+			Content: fmt.Sprintf("All entries with category of '%s'.", category),
+			Links: []OPDSLink{{
+				Rel:  "subsection",
+				Href: utils.RootURL(r, catalogRoot+"/entries?category="+url.QueryEscape(category)),
+				Type: opdsAcquisitionType,
+			}},
+		})
+	}
+
+	writeXML(w, feed, opdsNavigationType)
+}
+
+func (h *CatalogHandler) handleLanguages(w http.ResponseWriter, r *http.Request) {
+	feed := h.newFeed(r, catalogID("languages"), "List of languages", utils.RootURL(r, catalogRoot+"/languages"), opdsNavigationType)
+	feed.XmlnsThr = "http://purl.org/syndication/thread/1.0"
+
+	counts := make(map[string]int)
+	for _, archive := range h.ArchiveService.ListArchives(r.Context()) {
+		for _, language := range strings.FieldsFunc(archive.Metadata.Language, func(r rune) bool { return r == ',' || r == ';' }) {
+			if language = strings.TrimSpace(language); language != "" {
+				counts[language]++
+			}
+		}
+	}
+
+	languages := make([]string, 0, len(counts))
+	for language := range counts {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	for _, language := range languages {
+		feed.Entries = append(feed.Entries, OPDSEntry{
+			ID:       catalogID("language:" + language),
+			Title:    utils.GetLanguageName(language),
+			Updated:  feed.Updated,
+			Language: language,
+			Links: []OPDSLink{{
+				Rel:   "subsection",
+				Href:  utils.RootURL(r, catalogRoot+"/entries?lang="+url.QueryEscape(language)),
+				Type:  opdsAcquisitionType,
+				Count: counts[language],
+			}},
+		})
+	}
+
+	writeXML(w, feed, opdsNavigationType)
+}
+
+func (h *CatalogHandler) handleIllustration(w http.ResponseWriter, r *http.Request, uuid string) {
+	archive, exists := h.ArchiveService.GetArchiveByUUID(r.Context(), strings.TrimSuffix(uuid, "/"))
+	if !exists {
+		http.NotFound(w, r)
+		return
+	}
+
+	size := 48
+	if sizeStr := r.URL.Query().Get("size"); sizeStr != "" {
+		if s, err := strconv.Atoi(sizeStr); err == nil && s > 0 {
+			size = s
+		}
+	}
+
+	illustration, found := h.ArchiveService.FindIllustration(archive, size, 1)
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+
+	content, err := archive.Reader.GetContent(illustration.Entry)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read illustration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	mimeType, _ := archive.Reader.GetMimeType(illustration.Entry)
+	if mimeType == "" {
+		mimeType = "image/png"
+	}
+
+	w.Header().Set("Content-Type", mimeType)
+	utils.SetContentCacheHeaders(w, utils.ContentETag(archive.UUID, illustration.Entry, ""), h.CacheMaxAge, true)
+
+	http.ServeContent(w, r, "illustration", timeZero, bytes.NewReader(content))
+}
+
+func (h *CatalogHandler) lastUpdated(r *http.Request) string {
+	latest := ""
+	for _, archive := range h.ArchiveService.ListArchives(r.Context()) {
+		if date := catalogDate(archive.Metadata.Date); date > latest {
+			latest = date
+		}
+	}
+
+	if latest == "" {
+		return catalogDate("")
+	}
+	return latest
+}
+
+func newOPDSEntry(archive *services.Archive, root string) OPDSEntry {
+	entry := newPartialOPDSEntry(archive, root)
+	metadata := archive.Metadata
+
+	articleCount := archive.ArticleCount
+	mediaCount := archive.MediaCount
+
+	entry.Summary = metadata.Description
+	entry.Language = metadata.Language
+	entry.Name = metadata.Name
+	if entry.Name == "" {
+		entry.Name = archive.Name
+	}
+	entry.Flavour = metadata.Flavour
+	entry.Category = catalogCategory(archive)
+	entry.Tags = metadata.Tags
+	entry.ArticleCount = &articleCount
+	entry.MediaCount = &mediaCount
+	entry.Issued = catalogDate(metadata.Date)
+
+	if metadata.Creator != "" {
+		entry.Author = &OPDSPerson{Name: metadata.Creator}
+	}
+	if metadata.Publisher != "" {
+		entry.Publisher = &OPDSPerson{Name: metadata.Publisher}
+	}
+
+	entry.Links = []OPDSLink{
+		{
+			Rel:  "http://opds-spec.org/image/thumbnail",
+			Href: fmt.Sprintf("%s%s/illustration/%s/?size=48", root, catalogRoot, archive.UUID),
+			Type: "image/png;width=48;height=48;scale=1",
+		},
+		{
+			Type: "text/html",
+			Href: fmt.Sprintf("%s/content/%s", root, archive.Name),
+		},
+	}
+
+	if archive.Downloadable {
+		entry.Links = append(entry.Links, OPDSLink{
+			Rel:    "http://opds-spec.org/acquisition/open-access",
+			Href:   fmt.Sprintf("%s/download/%s.zim", root, archive.Name),
+			Type:   "application/x-zim",
+			Length: archive.Size,
+		})
+	}
+
+	return entry
+}
+
+func newPartialOPDSEntry(archive *services.Archive, root string) OPDSEntry {
+	return OPDSEntry{
+		ID:      "urn:uuid:" + archive.UUID,
+		Title:   archive.Metadata.Title,
+		Updated: catalogDate(archive.Metadata.Date),
+		Links: []OPDSLink{{
+			Rel:  "alternate",
+			Href: fmt.Sprintf("%s%s/entry/%s", root, catalogRoot, archive.UUID),
+			Type: opdsEntryType,
+		}},
+	}
+}
+
+func catalogCategory(archive *services.Archive) string {
+	for _, tag := range archive.Tags() {
+		if strings.HasPrefix(tag, "_category:") {
+			return strings.TrimPrefix(tag, "_category:")
+		}
+	}
+	return strings.ToLower(archive.Metadata.Category)
+}
+
+func catalogDate(date string) string {
+	if t, err := time.Parse("2006-01-02", date); err == nil {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return "1970-01-01T00:00:00Z"
+}
+
+func catalogID(key string) string {
+	sum := md5.Sum([]byte("zimserver-catalog:" + key))
+	return "urn:uuid:" + utils.FormatUUID(sum)
+}
+
+func writeXML(w http.ResponseWriter, v interface{}, contentType string) {
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	w.Write([]byte(xml.Header))
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		slog.Error("XML encoding error", "error", err)
+	}
+}
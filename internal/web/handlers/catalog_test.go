@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gaetanlhf/ZIMServer/internal/web/services"
+)
+
+func TestCatalogFeeds(t *testing.T) {
+	handler := &CatalogHandler{ArchiveService: services.NewArchiveService()}
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+		contains    []string
+	}{
+		{"/catalog/v2/root.xml", http.StatusOK, opdsNavigationType, []string{
+			`<link rel="self" href="/catalog/v2/root.xml"`,
+			`<link rel="subsection" href="/catalog/v2/entries" type="` + opdsAcquisitionType + `"`,
+			`<link rel="subsection" href="/catalog/v2/categories" type="` + opdsNavigationType + `"`,
+			`<updated>1970-01-01T00:00:00Z</updated>`,
+		}},
+		{"/catalog/v2/", http.StatusOK, opdsNavigationType, []string{`<title>ZIMServer library</title>`}},
+		{"/catalog/v2/searchdescription.xml", http.StatusOK, openSearchType, []string{
+			`<OpenSearchDescription xmlns="http://a9.com/-/spec/opensearch/1.1/">`,
+			`template="/catalog/v2/entries?q={searchTerms?}`,
+		}},
+		{"/catalog/v2/entries", http.StatusOK, opdsAcquisitionType, []string{
+			`<opensearch:totalResults>0</opensearch:totalResults>`,
+		}},
+		{"/catalog/v2/partial_entries", http.StatusOK, opdsAcquisitionType, nil},
+		{"/catalog/v2/categories", http.StatusOK, opdsNavigationType, nil},
+		{"/catalog/v2/languages", http.StatusOK, opdsNavigationType, nil},
+		{"/catalog/v2/entry/00000000-0000-0000-0000-000000000000", http.StatusNotFound, "", nil},
+		{"/catalog/v2/unknown", http.StatusNotFound, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType+"; charset=utf-8" {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+
+			body := rec.Body.String()
+			if !strings.HasPrefix(body, xml.Header) {
+				t.Errorf("missing XML declaration\n%s", body)
+			}
+			if err := xml.Unmarshal(rec.Body.Bytes(), new(struct{})); err != nil {
+				t.Errorf("invalid XML: %v\n%s", err, body)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(body, want) {
+					t.Errorf("output missing %q\n%s", want, body)
+				}
+			}
+		})
+	}
+}
+
+func TestOPDSEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		archive  *services.Archive
+		contains []string
+		excludes []string
+	}{
+		{
+			name: "downloadable",
+			archive: &services.Archive{
+				Name:         "wikipedia_en",
+				UUID:         "30313233-3435-3637-3839-616263646566",
+				Size:         4096,
+				ArticleCount: 12,
+				MediaCount:   3,
+				Downloadable: true,
+				Metadata: services.Metadata{
+					Title:     "Wikipedia & friends",
+					Language:  "eng",
+					Creator:   "Wikipedia",
+					Publisher: "Kiwix",
+					Date:      "2024-05-01",
+					Tags:      "_category:wikipedia;_pictures:yes",
+				},
+			},
+			contains: []string{
+				`<id>urn:uuid:30313233-3435-3637-3839-616263646566</id>`,
+				`<title>Wikipedia &amp; friends</title>`,
+				`<updated>2024-05-01T00:00:00Z</updated>`,
+				`<dc:issued>2024-05-01T00:00:00Z</dc:issued>`,
+				`<name>wikipedia_en</name>`,
+				`<category>wikipedia</category>`,
+				`<articleCount>12</articleCount>`,
+				`<mediaCount>3</mediaCount>`,
+				`<author>`,
+				`<publisher>`,
+				`href="/lib/catalog/v2/illustration/30313233-3435-3637-3839-616263646566/?size=48"`,
+				`href="/lib/content/wikipedia_en"`,
+				`rel="http://opds-spec.org/acquisition/open-access" href="/lib/download/wikipedia_en.zim" type="application/x-zim" length="4096"`,
+			},
+		},
+		{
+			name: "not downloadable",
+			archive: &services.Archive{
+				Name: "private",
+				UUID: "00000000-0000-0000-0000-000000000001",
+				Metadata: services.Metadata{
+					Title:    "Private",
+					Category: "Gutenberg",
+				},
+			},
+			contains: []string{
+				`<updated>1970-01-01T00:00:00Z</updated>`,
+				`<category>gutenberg</category>`,
+				`<articleCount>0</articleCount>`,
+			},
+			excludes: []string{"/download/", "<author>", "<publisher>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := xml.Marshal(newOPDSEntry(tt.archive, "/lib"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := string(output)
+
+			for _, want := range tt.contains {
+				if !strings.Contains(body, want) {
+					t.Errorf("entry missing %q\n%s", want, body)
+				}
+			}
+			for _, exclude := range tt.excludes {
+				if strings.Contains(body, exclude) {
+					t.Errorf("entry contains %q\n%s", exclude, body)
+				}
+			}
+
+			partial, err := xml.Marshal(newPartialOPDSEntry(tt.archive, "/lib"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := `<link rel="alternate" href="/lib/catalog/v2/entry/` + tt.archive.UUID + `" type="` + opdsEntryType + `">`
+			if !strings.Contains(string(partial), want) {
+				t.Errorf("partial entry missing %q\n%s", want, partial)
+			}
+		})
+	}
+}
@@ -1,8 +1,9 @@
 package handlers
 
 import (
+	"bytes"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -17,6 +18,7 @@ type ContentHandler struct {
 	ArchiveService *services.ArchiveService
 	FaviconService *services.FaviconService
 	Templates      TemplateRenderer
+	CacheMaxAge    time.Duration
 }
 
 var timeZero = time.Time{}
@@ -27,7 +29,7 @@ func (h *ContentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	if path != "" && !strings.Contains(path, "/") {
 		if !strings.HasSuffix(originalPath, "/") {
-			http.Redirect(w, r, originalPath+"/", http.StatusMovedPermanently)
+			http.Redirect(w, r, utils.RootURL(r, originalPath+"/"), http.StatusMovedPermanently)
 			return
 		}
 	}
@@ -40,7 +42,7 @@ func (h *ContentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	archiveName := parts[0]
-	archive, exists := h.ArchiveService.GetArchive(archiveName)
+	archive, exists := h.ArchiveService.GetArchive(r.Context(), archiveName)
 	if !exists {
 		h.handle404(w, r, "", "")
 		return
@@ -59,7 +61,7 @@ func (h *ContentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		mainPageURL := fmt.Sprintf("/content/%s/%s", archiveName, resolvedPage.GetPath())
+		mainPageURL := utils.RootURL(r, fmt.Sprintf("/content/%s/%s", archiveName, resolvedPage.GetPath()))
 		http.Redirect(w, r, mainPageURL, http.StatusFound)
 		return
 	}
@@ -76,6 +78,9 @@ func (h *ContentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (h *ContentHandler) handleResource(w http.ResponseWriter, r *http.Request, archive *services.Archive, resourcePath string) {
 	entry, err := archive.FS.GetEntry(resourcePath)
 	if err != nil {
+		if h.handleLegacyPath(w, r, archive, resourcePath) {
+			return
+		}
 		h.handle404(w, r, archive.Name, resourcePath)
 		return
 	}
@@ -84,19 +89,29 @@ func (h *ContentHandler) handleResource(w http.ResponseWriter, r *http.Request,
 		resolvedEntry, err := archive.Reader.ResolveRedirect(entry)
 		if err != nil {
 			http.Error(w, "Failed to resolve redirect", http.StatusInternalServerError)
-			log.Printf("Redirect resolution error for %s: %v", resourcePath, err)
+			slog.Error("Redirect resolution error", "path", resourcePath, "error", err)
 			return
 		}
 
 		targetPath := resolvedEntry.GetPath()
-		redirectURL := fmt.Sprintf("/content/%s/%s", archive.Name, targetPath)
+		redirectURL := utils.RootURL(r, fmt.Sprintf("/content/%s/%s", archive.Name, targetPath))
 
-		log.Printf("Redirect: %s -> %s", resourcePath, targetPath)
+		slog.Debug("Redirect", "from", resourcePath, "to", targetPath)
 
 		http.Redirect(w, r, redirectURL, http.StatusMovedPermanently)
 		return
 	}
 
+	mimeType, _ := archive.Reader.GetMimeType(entry)
+	if mimeType == "" {
+		mimeType = utils.GuessMimeType(resourcePath)
+	}
+
+	if strings.HasPrefix(mimeType, "text/html") {
+		h.handleHTML(w, r, archive, entry, mimeType)
+		return
+	}
+
 	file, err := archive.FS.Open(resourcePath)
 	if err != nil {
 		h.handle404(w, r, archive.Name, resourcePath)
@@ -104,11 +119,6 @@ func (h *ContentHandler) handleResource(w http.ResponseWriter, r *http.Request,
 	}
 	defer file.Close()
 
-	mimeType, _ := archive.Reader.GetMimeType(entry)
-	if mimeType == "" {
-		mimeType = utils.GuessMimeType(resourcePath)
-	}
-
 	if mimeType != "" {
 		if mimeType == "text/html" && !strings.Contains(mimeType, "charset") {
 			mimeType += "; charset=utf-8"
@@ -116,9 +126,136 @@ func (h *ContentHandler) handleResource(w http.ResponseWriter, r *http.Request,
 		w.Header().Set("Content-Type", mimeType)
 	}
 
+	utils.SetContentCacheHeaders(w, utils.ContentETag(archive.UUID, entry, ""), h.CacheMaxAge, false)
+
 	http.ServeContent(w, r, filepath.Base(resourcePath), timeZero, file.(http.File))
 }
 
+func (h *ContentHandler) handleHTML(w http.ResponseWriter, r *http.Request, archive *services.Archive, entry zimreader.DirectoryEntry, mimeType string) {
+	content, err := archive.Reader.GetContent(entry)
+	if err != nil {
+		http.Error(w, "Failed to read entry", http.StatusInternalServerError)
+		slog.Error("Content read error", "path", entry.GetPath(), "error", err)
+		return
+	}
+
+	root := utils.URLRoot(r.Context())
+	content = utils.RewriteHTML(content, root, archive.Name, entry.GetPath())
+
+	if !strings.Contains(mimeType, "charset") {
+		mimeType += "; charset=utf-8"
+	}
+
+	w.Header().Set("Content-Type", mimeType)
+	utils.SetContentCacheHeaders(w, utils.ContentETag(archive.UUID, entry, root), h.CacheMaxAge, false)
+
+	http.ServeContent(w, r, filepath.Base(entry.GetPath()), timeZero, bytes.NewReader(content))
+}
+
+func (h *ContentHandler) handleLegacyPath(w http.ResponseWriter, r *http.Request, archive *services.Archive, resourcePath string) bool {
+	if len(resourcePath) < 3 || resourcePath[1] != '/' {
+		return false
+	}
+
+	namespace := resourcePath[0]
+	switch namespace {
+	case 'A', 'I', 'J', '-':
+	default:
+		return false
+	}
+
+	path := resourcePath[2:]
+
+	if entry, err := archive.Reader.GetEntryByURL(namespace, path); err == nil {
+		h.serveEntry(w, r, archive, entry, path)
+		return true
+	}
+
+	if _, err := archive.FS.GetEntry(path); err != nil {
+		return false
+	}
+
+	redirectURL := utils.RootURL(r, fmt.Sprintf("/content/%s/%s", archive.Name, path))
+	if r.URL.RawQuery != "" {
+		redirectURL += "?" + r.URL.RawQuery
+	}
+
+	http.Redirect(w, r, redirectURL, http.StatusFound)
+	return true
+}
+
+func (h *ContentHandler) ServeRaw(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/raw/"), "/", 3)
+	if len(parts) < 3 || parts[2] == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	archive, exists := h.ArchiveService.GetArchive(r.Context(), parts[0])
+	if !exists {
+		http.NotFound(w, r)
+		return
+	}
+
+	kind, path := parts[1], parts[2]
+
+	var namespace byte
+	switch kind {
+	case "content":
+		namespace = zimreader.NamespaceContent
+	case "meta":
+		namespace = zimreader.NamespaceMetadata
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	entry, err := archive.Reader.GetEntryByURL(namespace, path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if entry.IsRedirect() {
+		resolvedEntry, err := archive.Reader.ResolveRedirect(entry)
+		if err != nil {
+			http.Error(w, "Failed to resolve redirect", http.StatusInternalServerError)
+			return
+		}
+
+		redirectURL := utils.RootURL(r, fmt.Sprintf("/raw/%s/%s/%s", archive.Name, kind, resolvedEntry.GetPath()))
+		http.Redirect(w, r, redirectURL, http.StatusFound)
+		return
+	}
+
+	h.serveEntry(w, r, archive, entry, path)
+}
+
+func (h *ContentHandler) serveEntry(w http.ResponseWriter, r *http.Request, archive *services.Archive, entry zimreader.DirectoryEntry, path string) {
+	content, err := archive.Reader.GetContent(entry)
+	if err != nil {
+		http.Error(w, "Failed to read entry", http.StatusInternalServerError)
+		slog.Error("Content read error", "path", path, "error", err)
+		return
+	}
+
+	mimeType, _ := archive.Reader.GetMimeType(entry)
+	if mimeType == "" {
+		mimeType = utils.GuessMimeType(path)
+	}
+	if mimeType == "" || (entry.GetNamespace() == zimreader.NamespaceMetadata && strings.HasPrefix(mimeType, "text/plain")) {
+		mimeType = "text/plain; charset=utf-8"
+	}
+	if mimeType == "text/html" {
+		mimeType += "; charset=utf-8"
+	}
+
+	w.Header().Set("Content-Type", mimeType)
+	utils.SetContentCacheHeaders(w, utils.ContentETag(archive.UUID, entry, ""), h.CacheMaxAge, false)
+
+	http.ServeContent(w, r, filepath.Base(path), timeZero, bytes.NewReader(content))
+}
+
 func (h *ContentHandler) handleFavicon(w http.ResponseWriter, r *http.Request, archive *services.Archive) {
 	faviconPaths := []struct {
 		namespace byte
@@ -151,7 +288,9 @@ func (h *ContentHandler) handleFavicon(w http.ResponseWriter, r *http.Request, a
 		}
 
 		w.Header().Set("Content-Type", mimeType)
-		w.Write(content)
+		utils.SetContentCacheHeaders(w, utils.ContentETag(archive.UUID, entry, ""), h.CacheMaxAge, false)
+
+		http.ServeContent(w, r, "favicon.ico", timeZero, bytes.NewReader(content))
 		return
 	}
 
@@ -169,19 +308,19 @@ func (h *ContentHandler) handle404(w http.ResponseWriter, r *http.Request, archi
 	}
 
 	if archiveName != "" {
-		archive, exists := h.ArchiveService.GetArchive(archiveName)
+		archive, exists := h.ArchiveService.GetArchive(r.Context(), archiveName)
 		if exists {
 			mainPage, err := archive.Reader.GetMainPage()
 			if err == nil {
 				resolvedPage, err := archive.Reader.ResolveRedirect(mainPage)
 				if err == nil {
-					data.HomeURL = fmt.Sprintf("/content/%s/%s", archiveName, resolvedPage.GetPath())
+					data.HomeURL = utils.RootURL(r, fmt.Sprintf("/content/%s/%s", archiveName, resolvedPage.GetPath()))
 				}
 			}
 		}
 	}
 
-	if err := h.Templates.Render(w, "404", data); err != nil {
-		log.Printf("Template error: %v", err)
+	if err := h.Templates.Render(w, r, "404", data); err != nil {
+		slog.Error("Template error", "error", err)
 	}
 }
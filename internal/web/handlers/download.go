@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gaetanlhf/ZIMServer/internal/web/services"
+)
+
+type DownloadHandler struct {
+	ArchiveService  *services.ArchiveService
+	ChecksumService *services.ChecksumService
+}
+
+func (h *DownloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	fileName := strings.TrimPrefix(r.URL.Path, "/download/")
+	if fileName == "" || strings.Contains(fileName, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	var algorithm services.ChecksumAlgorithm
+	if ext := filepath.Ext(fileName); ext != ".zim" {
+		parsed, ok := services.ParseChecksumAlgorithm(strings.TrimPrefix(ext, "."))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		algorithm = parsed
+		fileName = strings.TrimSuffix(fileName, ext)
+	}
+
+	archiveName, found := strings.CutSuffix(fileName, ".zim")
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+
+	archive, exists := h.ArchiveService.GetArchive(r.Context(), archiveName)
+	if !exists || !archive.Downloadable {
+		http.NotFound(w, r)
+		return
+	}
+
+	if algorithm != "" {
+		h.handleChecksum(w, r, archive, algorithm)
+		return
+	}
+
+	h.handleFile(w, r, archive)
+}
+
+func (h *DownloadHandler) handleFile(w http.ResponseWriter, r *http.Request, archive *services.Archive) {
+	file, err := os.Open(archive.Path)
+	if err != nil {
+		slog.Error("Download error", "archive", archive.Name, "error", err)
+		http.Error(w, "Archive file not available", http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, "Archive file not available", http.StatusInternalServerError)
+		return
+	}
+
+	fileName := archive.Name + ".zim"
+
+	w.Header().Set("Content-Type", "application/x-zim")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.Header().Set("ETag", fmt.Sprintf("%q", archive.UUID))
+
+	http.ServeContent(w, r, fileName, info.ModTime(), file)
+}
+
+func (h *DownloadHandler) handleChecksum(w http.ResponseWriter, r *http.Request, archive *services.Archive, algorithm services.ChecksumAlgorithm) {
+	sum, err := h.ChecksumService.Get(archive, algorithm)
+	if err != nil {
+		slog.Error("Checksum error", "archive", archive.Name, "error", err)
+		http.Error(w, fmt.Sprintf("Failed to compute checksum: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("ETag", fmt.Sprintf("\"%s-%s\"", archive.UUID, algorithm))
+	fmt.Fprintf(w, "%s  %s.zim\n", sum, archive.Name)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gaetanlhf/ZIMServer/internal/web/services"
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
+)
+
+type GalleryHandler struct {
+	ArchiveService *services.ArchiveService
+	FaviconService *services.FaviconService
+	Templates      TemplateRenderer
+}
+
+type GalleryData struct {
+	ArchiveName  string
+	ArchiveTitle string
+	FaviconURL   string
+	FaviconType  string
+	MediaType    string
+}
+
+func (h *GalleryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	originalPath := r.URL.Path
+	path := strings.TrimPrefix(originalPath, "/gallery/")
+
+	if path != "" && !strings.Contains(path, "/") {
+		if !strings.HasSuffix(originalPath, "/") {
+			http.Redirect(w, r, utils.RootURL(r, originalPath+"/"), http.StatusMovedPermanently)
+			return
+		}
+	}
+
+	archiveName := strings.TrimSuffix(path, "/")
+	if archiveName == "" || strings.Contains(archiveName, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	archive, exists := h.ArchiveService.GetArchive(r.Context(), archiveName)
+	if !exists {
+		http.NotFound(w, r)
+		return
+	}
+
+	mediaType := services.MediaImage
+	if parsed, ok := services.ParseMediaType(r.URL.Query().Get("type")); ok {
+		mediaType = parsed
+	}
+
+	faviconURL, faviconType := h.FaviconService.GetFaviconInfo(archive, archiveName)
+
+	data := GalleryData{
+		ArchiveName:  archiveName,
+		ArchiveTitle: archive.Metadata.Title,
+		FaviconURL:   faviconURL,
+		FaviconType:  faviconType,
+		MediaType:    string(mediaType),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if err := h.Templates.Render(w, r, "gallery", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		slog.Error("Template error", "error", err)
+	}
+}
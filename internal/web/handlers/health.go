@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gaetanlhf/ZIMServer/internal/web/services"
+)
+
+type HealthHandler struct {
+	ArchiveService *services.ArchiveService
+	Next           http.Handler
+}
+
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/healthz":
+		writeProbe(w, http.StatusOK, "ok")
+	case "/readyz":
+		if h.ArchiveService.Ready() {
+			writeProbe(w, http.StatusOK, "ready")
+			return
+		}
+		writeProbe(w, http.StatusServiceUnavailable, "not ready")
+	default:
+		h.Next.ServeHTTP(w, r)
+	}
+}
+
+func writeProbe(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	fmt.Fprintln(w, body)
+}
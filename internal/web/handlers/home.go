@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/gaetanlhf/ZIMServer/internal/web/services"
@@ -18,28 +18,42 @@ type HomeData struct {
 	Count      int
 	Languages  []services.LanguageInfo
 	Categories []string
+	Downloads  []*services.Archive
 	Version    string
+	User       string
 }
 
 type TemplateRenderer interface {
-	Render(w http.ResponseWriter, name string, data interface{}) error
+	Render(w http.ResponseWriter, r *http.Request, name string, data interface{}) error
 }
 
 func (h *HomeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	archives := h.ArchiveService.ListArchives()
+	archives := h.ArchiveService.ListArchives(r.Context())
+
+	downloads := make([]*services.Archive, 0)
+	for _, archive := range archives {
+		if archive.Downloadable {
+			downloads = append(downloads, archive)
+		}
+	}
 
 	data := HomeData{
 		Archives:   archives,
 		Count:      len(archives),
-		Languages:  h.ArchiveService.GetLanguages(),
-		Categories: h.ArchiveService.GetCategories(),
+		Languages:  h.ArchiveService.GetLanguages(r.Context()),
+		Categories: h.ArchiveService.GetCategories(r.Context()),
+		Downloads:  downloads,
 		Version:    h.Version,
 	}
 
+	if principal, ok := services.PrincipalFromContext(r.Context()); ok && principal.Kind == services.PrincipalUser {
+		data.User = principal.Name
+	}
+
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
-	if err := h.Templates.Render(w, "home", data); err != nil {
+	if err := h.Templates.Render(w, r, "home", data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Printf("Template error: %v", err)
+		slog.Error("Template error", "error", err)
 	}
 }
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
+)
+
+type MetricsHandler struct {
+	Path    string
+	Token   string
+	Allow   []*net.IPNet
+	Metrics http.Handler
+	Next    http.Handler
+}
+
+func (h *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != h.Path {
+		h.Next.ServeHTTP(w, r)
+		return
+	}
+
+	if len(h.Allow) > 0 && !h.allowed(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if h.Token != "" {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(h.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ZIMServer metrics"`)
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	h.Metrics.ServeHTTP(w, r)
+}
+
+func (h *MetricsHandler) Protected() bool {
+	return h.Token != "" || len(h.Allow) > 0
+}
+
+func (h *MetricsHandler) allowed(r *http.Request) bool {
+	ip, ok := utils.ClientIPFromContext(r.Context())
+	if !ok {
+		return false
+	}
+	for _, network := range h.Allow {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gaetanlhf/ZIMServer/internal/web/services"
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
+)
+
+type ReadHandler struct {
+	ArchiveService *services.ArchiveService
+	FaviconService *services.FaviconService
+	Templates      TemplateRenderer
+}
+
+type ReadData struct {
+	ArchiveName  string
+	ArchiveTitle string
+	EntryPath    string
+	Title        string
+	FaviconURL   string
+	FaviconType  string
+	HasIndex     bool
+	Head         template.HTML
+	Body         template.HTML
+}
+
+func (h *ReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	originalPath := r.URL.Path
+	path := strings.TrimPrefix(originalPath, "/read/")
+
+	if path != "" && !strings.Contains(path, "/") {
+		if !strings.HasSuffix(originalPath, "/") {
+			http.Redirect(w, r, utils.RootURL(r, originalPath+"/"), http.StatusMovedPermanently)
+			return
+		}
+	}
+
+	parts := strings.SplitN(path, "/", 2)
+
+	if len(parts) == 0 || parts[0] == "" {
+		http.Redirect(w, r, utils.RootURL(r, "/"), http.StatusFound)
+		return
+	}
+
+	archiveName := parts[0]
+	archive, exists := h.ArchiveService.GetArchive(r.Context(), archiveName)
+	if !exists {
+		h.handle404(w, r, "")
+		return
+	}
+
+	if len(parts) == 1 || parts[1] == "" {
+		mainPage, err := archive.Reader.GetMainPage()
+		if err != nil {
+			h.handle404(w, r, archive.Name)
+			return
+		}
+
+		resolvedPage, err := archive.Reader.ResolveRedirect(mainPage)
+		if err != nil {
+			http.Error(w, "Failed to resolve main page", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, utils.RootURL(r, fmt.Sprintf("/read/%s/%s", archive.Name, resolvedPage.GetPath())), http.StatusFound)
+		return
+	}
+
+	entryPath := parts[1]
+
+	entry, err := archive.FS.GetEntry(entryPath)
+	if err != nil {
+		h.handle404(w, r, archive.Name)
+		return
+	}
+
+	if entry.IsRedirect() {
+		resolvedEntry, err := archive.Reader.ResolveRedirect(entry)
+		if err != nil {
+			http.Error(w, "Failed to resolve redirect", http.StatusInternalServerError)
+			slog.Error("Redirect resolution error", "path", entryPath, "error", err)
+			return
+		}
+
+		http.Redirect(w, r, utils.RootURL(r, fmt.Sprintf("/read/%s/%s", archive.Name, resolvedEntry.GetPath())), http.StatusMovedPermanently)
+		return
+	}
+
+	mimeType, _ := archive.Reader.GetMimeType(entry)
+	if mimeType == "" {
+		mimeType = utils.GuessMimeType(entryPath)
+	}
+
+	if !strings.HasPrefix(mimeType, "text/html") {
+		http.Redirect(w, r, utils.RootURL(r, fmt.Sprintf("/content/%s/%s", archive.Name, entryPath)), http.StatusFound)
+		return
+	}
+
+	content, err := archive.Reader.GetContent(entry)
+	if err != nil {
+		http.Error(w, "Failed to read entry", http.StatusInternalServerError)
+		slog.Error("Content read error", "path", entryPath, "error", err)
+		return
+	}
+
+	article := utils.RewriteArticle(content, utils.URLRoot(r.Context()), archive.Name, entry.GetPath())
+
+	title := entry.GetTitle()
+	if article.Title != "" {
+		title = article.Title
+	}
+
+	faviconURL, faviconType := h.FaviconService.GetFaviconInfo(archive, archive.Name)
+
+	data := ReadData{
+		ArchiveName:  archive.Name,
+		ArchiveTitle: archive.Metadata.Title,
+		EntryPath:    entryPath,
+		Title:        title,
+		FaviconURL:   faviconURL,
+		FaviconType:  faviconType,
+		HasIndex:     archive.IndexMgr != nil,
+		Head:         template.HTML(article.Head),
+		Body:         template.HTML(article.Body),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "script-src 'none'; object-src 'none'")
+
+	if err := h.Templates.Render(w, r, "read", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		slog.Error("Template error", "error", err)
+	}
+}
+
+func (h *ReadHandler) handle404(w http.ResponseWriter, r *http.Request, archiveName string) {
+	w.WriteHeader(http.StatusNotFound)
+
+	data := struct {
+		Url     string
+		HomeURL string
+	}{
+		Url: r.URL.Path,
+	}
+
+	if archiveName != "" {
+		data.HomeURL = utils.RootURL(r, fmt.Sprintf("/read/%s/", archiveName))
+	}
+
+	if err := h.Templates.Render(w, r, "404", data); err != nil {
+		slog.Error("Template error", "error", err)
+	}
+}
@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gaetanlhf/ZIMServer/internal/metrics"
+	"github.com/gaetanlhf/ZIMServer/internal/web/services"
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
+)
+
+type SearchHandler struct {
+	ArchiveService *services.ArchiveService
+	FaviconService *services.FaviconService
+	Templates      TemplateRenderer
+}
+
+type SearchPageData struct {
+	ArchiveName  string
+	ArchiveTitle string
+	FaviconURL   string
+	FaviconType  string
+	Pattern      string
+	Results      []SearchPageResult
+	Total        int
+	Start        int
+	End          int
+	PrevURL      string
+	NextURL      string
+	ReadMode     bool
+}
+
+type SearchPageResult struct {
+	Title string
+	URL   string
+	Path  string
+}
+
+type KiwixSuggestion struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+	Kind  string `json:"kind"`
+	Path  string `json:"path,omitempty"`
+}
+
+type KiwixSearchRSS struct {
+	XMLName     xml.Name           `xml:"rss"`
+	Version     string             `xml:"version,attr"`
+	XmlnsSearch string             `xml:"xmlns:opensearch,attr"`
+	XmlnsAtom   string             `xml:"xmlns:atom,attr"`
+	Channel     KiwixSearchChannel `xml:"channel"`
+}
+
+type KiwixSearchChannel struct {
+	Title        string            `xml:"title"`
+	Link         string            `xml:"link"`
+	Description  string            `xml:"description"`
+	TotalResults int               `xml:"opensearch:totalResults"`
+	StartIndex   int               `xml:"opensearch:startIndex"`
+	ItemsPerPage int               `xml:"opensearch:itemsPerPage"`
+	Query        KiwixSearchQuery  `xml:"opensearch:Query"`
+	Items        []KiwixSearchItem `xml:"item"`
+}
+
+type KiwixSearchQuery struct {
+	Role        string `xml:"role,attr"`
+	SearchTerms string `xml:"searchTerms,attr"`
+	StartIndex  int    `xml:"startIndex,attr"`
+	Count       int    `xml:"count,attr"`
+}
+
+type KiwixSearchItem struct {
+	Title string          `xml:"title"`
+	Link  string          `xml:"link"`
+	Book  KiwixSearchBook `xml:"book"`
+}
+
+type KiwixSearchBook struct {
+	Title string `xml:"title"`
+}
+
+const (
+	defaultSearchPageLength = 25
+	defaultSuggestCount     = 10
+	maxSearchPageLength     = 140
+)
+
+func pageLengthParam(value string, fallback int) int {
+	if n, err := strconv.Atoi(value); err == nil && n > 0 {
+		return min(n, maxSearchPageLength)
+	}
+	return fallback
+}
+
+func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	archive, ok := h.bookFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	if archive.IndexMgr == nil {
+		http.Error(w, "Search not available for this archive", http.StatusServiceUnavailable)
+		return
+	}
+
+	pattern := q.Get("pattern")
+	if pattern == "" {
+		http.Error(w, "Missing query parameter 'pattern'", http.StatusBadRequest)
+		return
+	}
+
+	start := 1
+	if startStr := q.Get("start"); startStr != "" {
+		if s, err := strconv.Atoi(startStr); err == nil && s > 0 {
+			start = s
+		}
+	}
+
+	pageLength := pageLengthParam(q.Get("pageLength"), defaultSearchPageLength)
+
+	searchStart := time.Now()
+	results, err := archive.IndexMgr.Search(pattern, -1)
+	metrics.ObserveSearch(archive.Name, "search", time.Since(searchStart))
+	if err != nil {
+		slog.Error("Search error", "error", err)
+		http.Error(w, fmt.Sprintf("Search failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	readMode := q.Get("mode") == "read"
+	pagePrefix := utils.RootURL(r, "/content/")
+	if readMode {
+		pagePrefix = utils.RootURL(r, "/read/")
+	}
+
+	total := len(results)
+	from := min(start-1, total)
+	to := min(from+pageLength, total)
+
+	page := make([]SearchPageResult, 0, to-from)
+	for _, result := range results[from:to] {
+		page = append(page, SearchPageResult{
+			Title: result.Entry.GetTitle(),
+			URL:   fmt.Sprintf("%s%s/%s", pagePrefix, archive.Name, result.Entry.GetPath()),
+			Path:  result.Entry.GetPath(),
+		})
+	}
+
+	if q.Get("format") == "xml" {
+		h.writeSearchXML(w, r, archive, pattern, page, total, start, pageLength)
+		return
+	}
+
+	faviconURL, faviconType := h.FaviconService.GetFaviconInfo(archive, archive.Name)
+
+	data := SearchPageData{
+		ArchiveName:  archive.Name,
+		ArchiveTitle: archive.Metadata.Title,
+		FaviconURL:   faviconURL,
+		FaviconType:  faviconType,
+		Pattern:      pattern,
+		Results:      page,
+		Total:        total,
+		Start:        from + 1,
+		End:          to,
+		ReadMode:     readMode,
+	}
+
+	if from > 0 {
+		data.PrevURL = searchPageURL(r, max(1, start-pageLength))
+	}
+	if to < total {
+		data.NextURL = searchPageURL(r, start+pageLength)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if err := h.Templates.Render(w, r, "search", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		slog.Error("Template error", "error", err)
+	}
+}
+
+func (h *SearchHandler) writeSearchXML(w http.ResponseWriter, r *http.Request, archive *services.Archive, pattern string, page []SearchPageResult, total, start, pageLength int) {
+	rss := KiwixSearchRSS{
+		Version:     "2.0",
+		XmlnsSearch: "http://a9.com/-/spec/opensearch/1.1/",
+		XmlnsAtom:   "http://www.w3.org/2005/Atom",
+		Channel: KiwixSearchChannel{
+			Title:        "Search: " + pattern,
+			Link:         utils.RootURL(r, r.URL.RequestURI()),
+			Description:  "Search result for " + pattern,
+			TotalResults: total,
+			StartIndex:   start,
+			ItemsPerPage: pageLength,
+			Query: KiwixSearchQuery{
+				Role:        "request",
+				SearchTerms: pattern,
+				StartIndex:  start,
+				Count:       pageLength,
+			},
+			Items: make([]KiwixSearchItem, 0, len(page)),
+		},
+	}
+
+	for _, result := range page {
+		rss.Channel.Items = append(rss.Channel.Items, KiwixSearchItem{
+			Title: result.Title,
+			Link:  result.URL,
+			Book:  KiwixSearchBook{Title: archive.Metadata.Title},
+		})
+	}
+
+	writeXML(w, rss, "application/rss+xml")
+}
+
+func (h *SearchHandler) ServeSuggest(w http.ResponseWriter, r *http.Request) {
+	archive, ok := h.bookFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	if archive.IndexMgr == nil {
+		http.Error(w, "Search not available for this archive", http.StatusServiceUnavailable)
+		return
+	}
+
+	term := r.URL.Query().Get("term")
+
+	count := pageLengthParam(r.URL.Query().Get("count"), defaultSuggestCount)
+
+	suggestions := make([]KiwixSuggestion, 0, count+1)
+
+	if term != "" {
+		start := time.Now()
+		results, err := archive.IndexMgr.Search(term, count)
+		metrics.ObserveSearch(archive.Name, "suggest", time.Since(start))
+		if err != nil {
+			slog.Error("Suggest error", "error", err)
+			http.Error(w, fmt.Sprintf("Search failed: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		for _, result := range results {
+			suggestions = append(suggestions, KiwixSuggestion{
+				Value: result.Entry.GetTitle(),
+				Label: result.Entry.GetTitle(),
+				Kind:  "path",
+				Path:  result.Entry.GetPath(),
+			})
+		}
+
+		suggestions = append(suggestions, KiwixSuggestion{
+			Value: term + " ",
+			Label: fmt.Sprintf("containing '%s'...", term),
+			Kind:  "pattern",
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(suggestions)
+}
+
+func (h *SearchHandler) ServeRandom(w http.ResponseWriter, r *http.Request) {
+	archive, ok := h.bookFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	if archive.IndexMgr == nil {
+		http.Error(w, "Random not available for this archive", http.StatusServiceUnavailable)
+		return
+	}
+
+	entry, err := archive.IndexMgr.GetRandomArticle()
+	if err != nil {
+		slog.Error("Random error", "archive", archive.Name, "error", err)
+		http.Error(w, fmt.Sprintf("Random failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	pagePrefix := utils.RootURL(r, "/content/")
+	if r.URL.Query().Get("mode") == "read" {
+		pagePrefix = utils.RootURL(r, "/read/")
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("%s%s/%s", pagePrefix, archive.Name, entry.GetPath()), http.StatusFound)
+}
+
+func (h *SearchHandler) bookFromQuery(w http.ResponseWriter, r *http.Request) (*services.Archive, bool) {
+	q := r.URL.Query()
+
+	if uuid := q.Get("books.id"); uuid != "" {
+		if archive, exists := h.ArchiveService.GetArchiveByUUID(r.Context(), uuid); exists {
+			return archive, true
+		}
+		http.Error(w, "No such book: "+uuid, http.StatusNotFound)
+		return nil, false
+	}
+
+	name := q.Get("content")
+	if name == "" {
+		name = q.Get("books.name")
+	}
+
+	if name == "" {
+		archives := h.ArchiveService.ListArchives(r.Context())
+		if len(archives) == 1 {
+			return archives[0], true
+		}
+		http.Error(w, "Missing query parameter 'content'", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if archive, exists := h.ArchiveService.GetArchive(r.Context(), name); exists {
+		return archive, true
+	}
+
+	archives, _ := h.ArchiveService.QueryArchives(r.Context(), services.ArchiveQuery{Name: name, Limit: 1})
+	if len(archives) > 0 {
+		return archives[0], true
+	}
+
+	http.Error(w, "No such book: "+name, http.StatusNotFound)
+	return nil, false
+}
+
+func searchPageURL(r *http.Request, start int) string {
+	q := r.URL.Query()
+	q.Set("start", strconv.Itoa(start))
+	return utils.RootURL(r, (&url.URL{Path: r.URL.Path, RawQuery: q.Encode()}).String())
+}
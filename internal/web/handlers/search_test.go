@@ -0,0 +1,29 @@
+package handlers
+
+import "testing"
+
+func TestPageLengthParam(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  int
+	}{
+		{"empty", "", defaultSuggestCount},
+		{"valid", "5", 5},
+		{"at limit", "140", maxSearchPageLength},
+		{"above limit", "141", maxSearchPageLength},
+		{"max int", "9223372036854775807", maxSearchPageLength},
+		{"overflow", "92233720368547758070", defaultSuggestCount},
+		{"zero", "0", defaultSuggestCount},
+		{"negative", "-3", defaultSuggestCount},
+		{"not a number", "ten", defaultSuggestCount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pageLengthParam(tt.value, defaultSuggestCount); got != tt.want {
+				t.Errorf("pageLengthParam(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
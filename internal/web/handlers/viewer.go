@@ -3,18 +3,20 @@ package handlers
 import (
 	"fmt"
 	"html/template"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
 
 	"github.com/gaetanlhf/ZIMServer/internal/web/services"
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
 )
 
 type ViewerHandler struct {
-	ArchiveService *services.ArchiveService
-	FaviconService *services.FaviconService
-	Templates      TemplateRenderer
+	ArchiveService  *services.ArchiveService
+	FaviconService  *services.FaviconService
+	BacklinkService *services.BacklinkService
+	Templates       TemplateRenderer
 }
 
 type ViewerData struct {
@@ -24,6 +26,7 @@ type ViewerData struct {
 	FaviconURL   string
 	FaviconType  string
 	HasIndex     bool
+	HasBacklinks bool
 	IsCatch      bool
 	CatchURL     string
 	CatchSrc     template.URL
@@ -41,7 +44,7 @@ func (h *ViewerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	if path != "" && !strings.Contains(path, "/") {
 		if !strings.HasSuffix(originalPath, "/") {
-			http.Redirect(w, r, originalPath+"/", http.StatusMovedPermanently)
+			http.Redirect(w, r, utils.RootURL(r, originalPath+"/"), http.StatusMovedPermanently)
 			return
 		}
 	}
@@ -49,12 +52,12 @@ func (h *ViewerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	parts := strings.SplitN(path, "/", 2)
 
 	if len(parts) == 0 || parts[0] == "" {
-		http.Redirect(w, r, "/", http.StatusFound)
+		http.Redirect(w, r, utils.RootURL(r, "/"), http.StatusFound)
 		return
 	}
 
 	archiveName := parts[0]
-	archive, exists := h.ArchiveService.GetArchive(archiveName)
+	archive, exists := h.ArchiveService.GetArchive(r.Context(), archiveName)
 	if !exists {
 		h.handle404(w, r, "", "")
 		return
@@ -73,7 +76,7 @@ func (h *ViewerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		mainPageURL := fmt.Sprintf("/viewer/%s/%s", archiveName, resolvedPage.GetPath())
+		mainPageURL := utils.RootURL(r, fmt.Sprintf("/viewer/%s/%s", archiveName, resolvedPage.GetPath()))
 		http.Redirect(w, r, mainPageURL, http.StatusFound)
 		return
 	}
@@ -83,6 +86,7 @@ func (h *ViewerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	faviconURL, faviconType := h.FaviconService.GetFaviconInfo(archive, archiveName)
 
 	hasIndex := archive.IndexMgr != nil
+	_, hasBacklinks := h.BacklinkService.GetIndex(archive.UUID)
 
 	data := ViewerData{
 		ArchiveName:  archiveName,
@@ -91,14 +95,15 @@ func (h *ViewerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		FaviconURL:   faviconURL,
 		FaviconType:  faviconType,
 		HasIndex:     hasIndex,
+		HasBacklinks: hasBacklinks,
 		IsCatch:      false,
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
-	if err := h.Templates.Render(w, "viewer", data); err != nil {
+	if err := h.Templates.Render(w, r, "viewer", data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Printf("Template error: %v", err)
+		slog.Error("Template error", "error", err)
 	}
 }
 
@@ -114,14 +119,14 @@ func (h *ViewerHandler) handleCatch(w http.ResponseWriter, r *http.Request) {
 		}
 
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		if err := h.Templates.Render(w, "catch", data); err != nil {
+		if err := h.Templates.Render(w, r, "catch", data); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
-			log.Printf("Template error: %v", err)
+			slog.Error("Template error", "error", err)
 		}
 		return
 	}
 
-	archive, exists := h.ArchiveService.GetArchive(viewer)
+	archive, exists := h.ArchiveService.GetArchive(r.Context(), viewer)
 	if !exists {
 		h.handle404(w, r, "", "")
 		return
@@ -138,14 +143,14 @@ func (h *ViewerHandler) handleCatch(w http.ResponseWriter, r *http.Request) {
 		HasIndex:     hasIndex,
 		IsCatch:      true,
 		CatchURL:     catchURL,
-		CatchSrc:     template.URL("/catch?url=" + url.QueryEscape(catchURL)),
+		CatchSrc:     template.URL(utils.RootURL(r, "/catch?url="+url.QueryEscape(catchURL))),
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
-	if err := h.Templates.Render(w, "viewer", data); err != nil {
+	if err := h.Templates.Render(w, r, "viewer", data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Printf("Template error: %v", err)
+		slog.Error("Template error", "error", err)
 	}
 }
 
@@ -160,19 +165,19 @@ func (h *ViewerHandler) handle404(w http.ResponseWriter, r *http.Request, archiv
 	}
 
 	if archiveName != "" {
-		archive, exists := h.ArchiveService.GetArchive(archiveName)
+		archive, exists := h.ArchiveService.GetArchive(r.Context(), archiveName)
 		if exists {
 			mainPage, err := archive.Reader.GetMainPage()
 			if err == nil {
 				resolvedPage, err := archive.Reader.ResolveRedirect(mainPage)
 				if err == nil {
-					data.HomeURL = fmt.Sprintf("/viewer/%s/%s", archiveName, resolvedPage.GetPath())
+					data.HomeURL = utils.RootURL(r, fmt.Sprintf("/viewer/%s/%s", archiveName, resolvedPage.GetPath()))
 				}
 			}
 		}
 	}
 
-	if err := h.Templates.Render(w, "404", data); err != nil {
-		log.Printf("Template error: %v", err)
+	if err := h.Templates.Render(w, r, "404", data); err != nil {
+		slog.Error("Template error", "error", err)
 	}
 }
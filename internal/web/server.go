@@ -2,78 +2,289 @@ package web
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/gaetanlhf/ZIMServer/internal/metrics"
 	"github.com/gaetanlhf/ZIMServer/internal/web/handlers"
 	"github.com/gaetanlhf/ZIMServer/internal/web/services"
 	"github.com/gaetanlhf/ZIMServer/internal/web/templates"
 	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
 )
 
+type Options struct {
+	Backlinks   bool
+	Library     *services.Library
+	NoDownload  []string
+	CacheMaxAge time.Duration
+
+	Compression          bool
+	CompressionCacheSize int64
+
+	RequestLog bool
+	AccessLog  *utils.AccessLog
+
+	Auth   *services.AuthService
+	Access *services.AccessPolicy
+
+	URLRoot         string
+	TrustedProxies  []*net.IPNet
+	TrustUnixSocket bool
+
+	Metrics *MetricsOptions
+}
+
+type MetricsOptions struct {
+	Path  string
+	Token string
+	Allow []*net.IPNet
+}
+
 type Server struct {
-	archiveService *services.ArchiveService
-	faviconService *services.FaviconService
-	searchService  *services.SearchService
-	homeHandler    *handlers.HomeHandler
-	viewerHandler  *handlers.ViewerHandler
-	contentHandler *handlers.ContentHandler
-	apiHandler     *handlers.APIHandler
+	version          string
+	templates        *templates.Templates
+	options          Options
+	archiveService   *services.ArchiveService
+	faviconService   *services.FaviconService
+	searchService    *services.SearchService
+	mediaService     *services.MediaService
+	backlinkService  *services.BacklinkService
+	linkCheckService *services.LinkCheckService
+	checksumService  *services.ChecksumService
+	compressionCache *utils.CompressionCache
+	handler          atomic.Value
+	mu               sync.Mutex
+}
+
+type router struct {
+	homeHandler     *handlers.HomeHandler
+	viewerHandler   *handlers.ViewerHandler
+	contentHandler  *handlers.ContentHandler
+	apiHandler      *handlers.APIHandler
+	galleryHandler  *handlers.GalleryHandler
+	catalogHandler  *handlers.CatalogHandler
+	searchHandler   *handlers.SearchHandler
+	downloadHandler *handlers.DownloadHandler
+	readHandler     *handlers.ReadHandler
 }
 
-func NewServer(version string) (*Server, error) {
+func NewServer(version string, options Options) (*Server, error) {
 	tmpl, err := templates.Load()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load templates: %w", err)
 	}
 
-	archiveService := services.NewArchiveService()
-	faviconService := services.NewFaviconService()
-	searchService := services.NewSearchService()
+	server := &Server{
+		version:          version,
+		templates:        tmpl,
+		archiveService:   services.NewArchiveService(),
+		faviconService:   services.NewFaviconService(),
+		searchService:    services.NewSearchService(),
+		mediaService:     services.NewMediaService(),
+		backlinkService:  services.NewBacklinkService(),
+		linkCheckService: services.NewLinkCheckService(),
+		checksumService:  services.NewChecksumService(),
+	}
+
+	server.Reload(options)
+
+	metrics.SetSources(metrics.Sources{
+		Archives: func() int {
+			return len(server.archiveService.AllArchives())
+		},
+		CompressionCache: server.compressionStats,
+	})
+
+	return server, nil
+}
+
+func (s *Server) Reload(options Options) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	return &Server{
-		archiveService: archiveService,
-		faviconService: faviconService,
-		searchService:  searchService,
+	library := options.Library
+	if library == nil {
+		library = &services.Library{}
+	}
+	s.archiveService.SetLibrary(library)
+	s.archiveService.DisableDownloads(options.NoDownload)
+	s.archiveService.SetAccessPolicy(options.Access)
+
+	rt := &router{
 		homeHandler: &handlers.HomeHandler{
-			ArchiveService: archiveService,
-			Templates:      tmpl,
-			Version:        version,
+			ArchiveService: s.archiveService,
+			Templates:      s.templates,
+			Version:        s.version,
 		},
 		viewerHandler: &handlers.ViewerHandler{
-			ArchiveService: archiveService,
-			FaviconService: faviconService,
-			Templates:      tmpl,
+			ArchiveService:  s.archiveService,
+			FaviconService:  s.faviconService,
+			BacklinkService: s.backlinkService,
+			Templates:       s.templates,
 		},
 		contentHandler: &handlers.ContentHandler{
-			ArchiveService: archiveService,
-			FaviconService: faviconService,
-			Templates:      tmpl,
+			ArchiveService: s.archiveService,
+			FaviconService: s.faviconService,
+			Templates:      s.templates,
+			CacheMaxAge:    options.CacheMaxAge,
 		},
 		apiHandler: &handlers.APIHandler{
-			ArchiveService: archiveService,
-			SearchService:  searchService,
+			ArchiveService:   s.archiveService,
+			SearchService:    s.searchService,
+			MediaService:     s.mediaService,
+			BacklinkService:  s.backlinkService,
+			LinkCheckService: s.linkCheckService,
+			CacheMaxAge:      options.CacheMaxAge,
+		},
+		galleryHandler: &handlers.GalleryHandler{
+			ArchiveService: s.archiveService,
+			FaviconService: s.faviconService,
+			Templates:      s.templates,
 		},
-	}, nil
+		catalogHandler: &handlers.CatalogHandler{
+			ArchiveService: s.archiveService,
+			CacheMaxAge:    options.CacheMaxAge,
+		},
+		searchHandler: &handlers.SearchHandler{
+			ArchiveService: s.archiveService,
+			FaviconService: s.faviconService,
+			Templates:      s.templates,
+		},
+		downloadHandler: &handlers.DownloadHandler{
+			ArchiveService:  s.archiveService,
+			ChecksumService: s.checksumService,
+		},
+		readHandler: &handlers.ReadHandler{
+			ArchiveService: s.archiveService,
+			FaviconService: s.faviconService,
+			Templates:      s.templates,
+		},
+	}
+
+	metricsPath := ""
+	var metricsHandler *handlers.MetricsHandler
+	if options.Metrics != nil {
+		metricsPath = options.Metrics.Path
+		metricsHandler = &handlers.MetricsHandler{
+			Path:    options.Metrics.Path,
+			Token:   options.Metrics.Token,
+			Allow:   options.Metrics.Allow,
+			Metrics: metrics.Handler(),
+		}
+	}
+
+	var handler http.Handler = utils.CacheMiddleware(rt)
+	if metricsHandler != nil && !metricsHandler.Protected() {
+		metricsHandler.Next = handler
+		handler = metricsHandler
+	}
+	if options.Access != nil {
+		handler = &handlers.AccessCacheHandler{
+			Access: options.Access,
+			Next:   handler,
+		}
+	}
+	if options.Auth != nil {
+		handler = &handlers.AuthHandler{
+			AuthService: options.Auth,
+			Templates:   s.templates,
+			Next:        handler,
+		}
+	}
+	if options.Compression {
+		if options.CompressionCacheSize <= 0 {
+			s.compressionCache = nil
+		} else if s.compressionCache == nil || s.options.CompressionCacheSize != options.CompressionCacheSize {
+			s.compressionCache = utils.NewCompressionCache(options.CompressionCacheSize)
+		}
+		handler = utils.CompressionMiddleware(handler, s.compressionCache)
+	}
+	handler = &handlers.HealthHandler{
+		ArchiveService: s.archiveService,
+		Next:           handler,
+	}
+	if metricsHandler != nil && metricsHandler.Protected() {
+		metricsHandler.Next = handler
+		handler = metricsHandler
+	}
+	handler = utils.ProxyMiddleware(handler, options.URLRoot, options.TrustedProxies, options.TrustUnixSocket)
+	if options.RequestLog || options.AccessLog != nil || metricsPath != "" {
+		handler = utils.LoggingMiddleware(handler, options.RequestLog, options.AccessLog, metricsPath)
+	}
+
+	s.options = options
+	s.handler.Store(handler)
+}
+
+func (s *Server) compressionStats() (hits, misses uint64, size int64) {
+	s.mu.Lock()
+	cache := s.compressionCache
+	s.mu.Unlock()
+
+	if cache == nil {
+		return 0, 0, 0
+	}
+	return cache.Stats()
 }
 
 func (s *Server) LoadZIM(path string) error {
-	return s.archiveService.LoadZIM(path)
+	archive, err := s.archiveService.LoadZIM(path)
+	if err != nil {
+		metrics.LoadFailed()
+		return err
+	}
+
+	s.mu.Lock()
+	backlinks := s.options.Backlinks
+	s.mu.Unlock()
+
+	if backlinks {
+		s.backlinkService.Build(archive)
+	}
+
+	return nil
 }
 
 func (s *Server) UnloadZIM(name string) error {
+	if archive, exists := s.archiveService.LoadedArchive(name); exists {
+		s.archiveService.SetState(archive.Path, services.StateUnloading, nil)
+		s.mediaService.Forget(archive.UUID)
+		s.backlinkService.Forget(archive.UUID)
+		s.linkCheckService.Forget(archive.UUID)
+		s.checksumService.Forget(archive.UUID)
+	}
 	return s.archiveService.UnloadZIM(name)
 }
 
+func (s *Server) SetArchiveState(path string, state services.ArchiveState, err error) {
+	s.archiveService.SetState(path, state, err)
+}
+
+func (s *Server) ForgetArchive(path string) {
+	s.archiveService.ForgetState(path)
+}
+
+func (s *Server) SetStarted() {
+	s.archiveService.SetStarted()
+}
+
+func (s *Server) Close() error {
+	return s.archiveService.Close()
+}
+
 func (s *Server) ListArchives() []*services.Archive {
-	return s.archiveService.ListArchives()
+	return s.archiveService.AllArchives()
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	utils.LoggingMiddleware(http.HandlerFunc(s.serveHTTP)).ServeHTTP(w, r)
+	s.handler.Load().(http.Handler).ServeHTTP(w, r)
 }
 
-func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+func (s *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Path
 
 	switch {
@@ -83,10 +294,26 @@ func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
 		http.StripPrefix("/assets/", http.FileServer(templates.GetAssetsFS())).ServeHTTP(w, r)
 	case strings.HasPrefix(path, "/viewer/"):
 		s.viewerHandler.ServeHTTP(w, r)
+	case strings.HasPrefix(path, "/read/"):
+		s.readHandler.ServeHTTP(w, r)
 	case strings.HasPrefix(path, "/content/"):
 		s.contentHandler.ServeHTTP(w, r)
+	case strings.HasPrefix(path, "/gallery/"):
+		s.galleryHandler.ServeHTTP(w, r)
 	case strings.HasPrefix(path, "/api/"):
 		s.apiHandler.ServeHTTP(w, r)
+	case strings.HasPrefix(path, "/catalog/"):
+		s.catalogHandler.ServeHTTP(w, r)
+	case strings.HasPrefix(path, "/download/"):
+		s.downloadHandler.ServeHTTP(w, r)
+	case strings.HasPrefix(path, "/raw/"):
+		s.contentHandler.ServeRaw(w, r)
+	case path == "/search":
+		s.searchHandler.ServeHTTP(w, r)
+	case path == "/suggest":
+		s.searchHandler.ServeSuggest(w, r)
+	case path == "/random":
+		s.searchHandler.ServeRandom(w, r)
 	case strings.HasPrefix(path, "/catch"):
 		s.viewerHandler.ServeHTTP(w, r)
 	default:
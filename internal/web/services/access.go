@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
+)
+
+type AccessRule struct {
+	Archives []string
+	Allow    []string
+}
+
+type AccessPolicy struct {
+	rules  []accessRule
+	groups map[string][]string
+}
+
+type accessRule struct {
+	archives []string
+	users    map[string]bool
+	groups   map[string]bool
+	tokens   map[string]bool
+	networks []*net.IPNet
+}
+
+func NewAccessPolicy(rules []AccessRule, groups map[string][]string) (*AccessPolicy, error) {
+	policy := &AccessPolicy{
+		groups: make(map[string][]string),
+	}
+
+	for group, members := range groups {
+		for _, member := range members {
+			policy.groups[member] = append(policy.groups[member], group)
+		}
+	}
+
+	for i, rule := range rules {
+		parsed := accessRule{
+			archives: rule.Archives,
+			users:    make(map[string]bool),
+			groups:   make(map[string]bool),
+			tokens:   make(map[string]bool),
+		}
+
+		for _, pattern := range rule.Archives {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("access rule %d: invalid archive pattern %q", i+1, pattern)
+			}
+		}
+
+		for _, entry := range rule.Allow {
+			kind, value, found := strings.Cut(entry, ":")
+			switch {
+			case found && kind == "user":
+				parsed.users[value] = true
+			case found && kind == "group":
+				parsed.groups[value] = true
+			case found && kind == "token":
+				parsed.tokens[value] = true
+			default:
+				network, err := utils.ParseNetwork(entry)
+				if err != nil {
+					return nil, fmt.Errorf("access rule %d: %q is not a user:, group:, token:, IP or CIDR entry", i+1, entry)
+				}
+				parsed.networks = append(parsed.networks, network)
+			}
+		}
+
+		policy.rules = append(policy.rules, parsed)
+	}
+
+	return policy, nil
+}
+
+func (p *AccessPolicy) Allows(ctx context.Context, archiveName string) bool {
+	restricted := false
+
+	principal, _ := PrincipalFromContext(ctx)
+	ip, _ := utils.ClientIPFromContext(ctx)
+
+	for _, rule := range p.rules {
+		if !rule.matches(archiveName) {
+			continue
+		}
+		restricted = true
+
+		if rule.allows(principal, ip, p.groups) {
+			return true
+		}
+	}
+
+	return !restricted
+}
+
+func (p *AccessPolicy) Restricts(archiveName string) bool {
+	for _, rule := range p.rules {
+		if rule.matches(archiveName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *accessRule) matches(archiveName string) bool {
+	for _, pattern := range r.archives {
+		if matched, _ := path.Match(pattern, archiveName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *accessRule) allows(principal *Principal, ip net.IP, groups map[string][]string) bool {
+	if principal != nil {
+		switch principal.Kind {
+		case PrincipalUser:
+			if r.users[principal.Name] {
+				return true
+			}
+			for _, group := range groups[principal.Name] {
+				if r.groups[group] {
+					return true
+				}
+			}
+		case PrincipalToken:
+			if r.tokens[principal.Name] {
+				return true
+			}
+		}
+	}
+
+	if ip != nil {
+		for _, network := range r.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
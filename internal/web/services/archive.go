@@ -1,10 +1,13 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
-	"path/filepath"
+	"log/slog"
+	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -14,33 +17,42 @@ import (
 	zimreader "github.com/gaetanlhf/ZIMServer/internal/zim/reader"
 )
 
-const (
-	colorReset  = "\033[0m"
-	colorGreen  = "\033[32m"
-	colorYellow = "\033[33m"
-	colorCyan   = "\033[36m"
-)
-
 type Archive struct {
-	Name     string
-	Path     string
-	Reader   *zimreader.ZIMReader
-	FS       *zimfs.ZIMFS
-	IndexMgr *index.Manager
-	Metadata Metadata
+	Name         string
+	Path         string
+	UUID         string
+	Size         int64
+	ArticleCount int
+	MediaCount   int
+	HasFullText  bool
+	Downloadable bool
+	Reader       *zimreader.ZIMReader
+	FS           *zimfs.ZIMFS
+	IndexMgr     *index.Manager
+	Metadata     Metadata
+
+	Illustrations []Illustration
 }
 
 type Metadata struct {
-	Title        string
-	Description  string
-	Language     string
-	LanguageCode string
-	Creator      string
-	Publisher    string
-	Date         string
-	Tags         string
-	Category     string
-	EntryCount   uint32
+	Title           string
+	Description     string
+	LongDescription string
+	Language        string
+	LanguageCode    string
+	Creator         string
+	Publisher       string
+	Date            string
+	Tags            string
+	Category        string
+	Name            string
+	Flavour         string
+	License         string
+	Source          string
+	Scraper         string
+	Counter         string
+	Relation        string
+	EntryCount      uint32
 }
 
 type LanguageInfo struct {
@@ -49,48 +61,107 @@ type LanguageInfo struct {
 }
 
 type ArchiveService struct {
-	archives map[string]*Archive
-	mu       sync.RWMutex
+	archives   map[string]*Archive
+	library    map[string]LibraryBook
+	noDownload map[string]bool
+	access     *AccessPolicy
+	states     map[string]*ArchiveStatus
+	started    bool
+	mu         sync.RWMutex
 }
 
 func NewArchiveService() *ArchiveService {
 	return &ArchiveService{
-		archives: make(map[string]*Archive),
+		archives:   make(map[string]*Archive),
+		library:    make(map[string]LibraryBook),
+		noDownload: make(map[string]bool),
+		states:     make(map[string]*ArchiveStatus),
+	}
+}
+
+func (s *ArchiveService) DisableDownloads(names []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.noDownload = make(map[string]bool)
+	for _, name := range names {
+		s.noDownload[name] = true
+	}
+	for name, archive := range s.archives {
+		archive.Downloadable = !s.noDownload["*"] && !s.noDownload[name]
+	}
+}
+
+func (s *ArchiveService) LoadZIM(path string) (*Archive, error) {
+	s.SetState(path, StateLoading, nil)
+
+	archive, err := s.loadZIM(path)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.setState(path, StateFailed, err)
+		return nil, err
 	}
+
+	archive.Downloadable = !s.noDownload["*"] && !s.noDownload[archive.Name]
+	s.archives[archive.Name] = archive
+	s.setState(path, StateLoaded, nil)
+
+	return archive, nil
 }
 
-func (s *ArchiveService) LoadZIM(path string) error {
+func (s *ArchiveService) loadZIM(path string) (*Archive, error) {
 	reader, err := zimreader.NewReader(path)
 	if err != nil {
-		return fmt.Errorf("failed to open ZIM: %w", err)
+		return nil, fmt.Errorf("failed to open ZIM: %w", err)
 	}
 
-	baseName := filepath.Base(path)
-	name := strings.TrimSuffix(baseName, filepath.Ext(baseName))
+	name := ArchiveName(path)
 
 	fs := zimfs.New(reader)
 
 	indexMgr, err := index.NewManager(reader)
 	if err != nil {
-		log.Printf("No search index for %s: %v", name, err)
+		slog.Info("No search index", "archive", name, "error", err)
 	}
 
 	metadata := s.extractMetadata(reader, name)
 
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		size = info.Size()
+	}
+
+	articleCount, mediaCount := parseCounter(metadata.Counter)
+	if articleCount == 0 && indexMgr != nil {
+		articleCount = indexMgr.ArticleCount()
+	}
+
+	_, fullTextErr := reader.GetEntryByURL(zimreader.NamespaceIndex, "fulltext/xapian")
+
 	archive := &Archive{
-		Name:     name,
-		Path:     path,
-		Reader:   reader,
-		FS:       fs,
-		IndexMgr: indexMgr,
-		Metadata: metadata,
+		Name:         name,
+		Path:         path,
+		UUID:         utils.FormatUUID(reader.GetHeader().UUID),
+		Size:         size,
+		ArticleCount: articleCount,
+		MediaCount:   mediaCount,
+		HasFullText:  fullTextErr == nil,
+		Reader:       reader,
+		FS:           fs,
+		IndexMgr:     indexMgr,
+		Metadata:     metadata,
+
+		Illustrations: listIllustrations(reader),
 	}
 
-	s.mu.Lock()
-	s.archives[name] = archive
-	s.mu.Unlock()
+	if book, exists := s.libraryBook(path); exists {
+		book.apply(archive)
+	}
 
-	return nil
+	return archive, nil
 }
 
 func (s *ArchiveService) extractMetadata(reader *zimreader.ZIMReader, name string) Metadata {
@@ -101,13 +172,21 @@ func (s *ArchiveService) extractMetadata(reader *zimreader.ZIMReader, name strin
 	}
 
 	keys := map[string]*string{
-		"Title":       &metadata.Title,
-		"Description": &metadata.Description,
-		"Language":    &metadata.Language,
-		"Creator":     &metadata.Creator,
-		"Publisher":   &metadata.Publisher,
-		"Date":        &metadata.Date,
-		"Tags":        &metadata.Tags,
+		"Title":           &metadata.Title,
+		"Description":     &metadata.Description,
+		"LongDescription": &metadata.LongDescription,
+		"Language":        &metadata.Language,
+		"Creator":         &metadata.Creator,
+		"Publisher":       &metadata.Publisher,
+		"Date":            &metadata.Date,
+		"Tags":            &metadata.Tags,
+		"Name":            &metadata.Name,
+		"Flavour":         &metadata.Flavour,
+		"License":         &metadata.License,
+		"Source":          &metadata.Source,
+		"Scraper":         &metadata.Scraper,
+		"Counter":         &metadata.Counter,
+		"Relation":        &metadata.Relation,
 	}
 
 	for key, ptr := range keys {
@@ -120,19 +199,48 @@ func (s *ArchiveService) extractMetadata(reader *zimreader.ZIMReader, name strin
 		metadata.Title = name
 	}
 
-	if metadata.Language != "" {
-		if strings.Contains(metadata.Language, ",") || strings.Contains(metadata.Language, ";") {
-			metadata.LanguageCode = "MUL"
-		} else {
-			metadata.LanguageCode = utils.GetLanguageCode(metadata.Language)
-		}
-	}
-
+	metadata.LanguageCode = languageCode(metadata.Language)
 	metadata.Category = extractMainCategory(metadata.Tags)
 
 	return metadata
 }
 
+func languageCode(language string) string {
+	if language == "" {
+		return ""
+	}
+	if strings.Contains(language, ",") || strings.Contains(language, ";") {
+		return "MUL"
+	}
+	return utils.GetLanguageCode(language)
+}
+
+func parseCounter(counter string) (int, int) {
+	articles, media := 0, 0
+
+	for _, item := range strings.Split(counter, ";") {
+		mimeType, countStr, found := strings.Cut(item, "=")
+		if !found {
+			continue
+		}
+
+		count, err := strconv.Atoi(strings.TrimSpace(countStr))
+		if err != nil {
+			continue
+		}
+
+		mimeType = strings.TrimSpace(mimeType)
+		switch {
+		case strings.HasPrefix(mimeType, "text/html"):
+			articles += count
+		case strings.HasPrefix(mimeType, "image/"), strings.HasPrefix(mimeType, "video/"), strings.HasPrefix(mimeType, "audio/"):
+			media += count
+		}
+	}
+
+	return articles, media
+}
+
 func extractMainCategory(tags string) string {
 	if tags == "" {
 		return ""
@@ -160,34 +268,107 @@ func extractMainCategory(tags string) string {
 
 func (s *ArchiveService) UnloadZIM(name string) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if _, exists := s.archives[name]; !exists {
+	archive, exists := s.archives[name]
+	if !exists {
+		s.mu.Unlock()
 		return fmt.Errorf("archive not found: %s", name)
 	}
-
 	delete(s.archives, name)
+	s.mu.Unlock()
+
+	err := archive.Reader.Close()
 
-	// Correction: Ajout de l'extension .zim
-	zimFileName := name + ".zim"
-	log.Printf("%sℹ%s Unloaded ZIM: %s%s%s", colorCyan, colorReset, colorCyan, zimFileName, colorReset)
+	s.mu.Lock()
+	if status, exists := s.states[name]; exists && status.Path == archive.Path && status.State == StateUnloading {
+		delete(s.states, name)
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to close %s: %w", name+".zim", err)
+	}
+
+	slog.Info("Unloaded ZIM", "file", name+".zim")
 	return nil
 }
 
-func (s *ArchiveService) GetArchive(name string) (*Archive, bool) {
+func (s *ArchiveService) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for name, archive := range s.archives {
+		if err := archive.Reader.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+		delete(s.archives, name)
+	}
+	s.started = false
+
+	return errors.Join(errs...)
+}
+
+func (s *ArchiveService) SetAccessPolicy(policy *AccessPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.access = policy
+}
+
+func (s *ArchiveService) visible(ctx context.Context, archive *Archive) bool {
+	return s.access == nil || s.access.Allows(ctx, archive.Name)
+}
+
+func (s *ArchiveService) LoadedArchive(name string) (*Archive, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	archive, exists := s.archives[name]
 	return archive, exists
 }
 
-func (s *ArchiveService) ListArchives() []*Archive {
+func (s *ArchiveService) GetArchive(ctx context.Context, name string) (*Archive, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	archive, exists := s.archives[name]
+	if !exists || !s.visible(ctx, archive) {
+		return nil, false
+	}
+	utils.SetRequestArchive(ctx, archive.Name)
+	return archive, true
+}
+
+func (s *ArchiveService) GetArchiveByUUID(ctx context.Context, uuid string) (*Archive, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, archive := range s.archives {
+		if !s.visible(ctx, archive) {
+			continue
+		}
+		if strings.EqualFold(archive.UUID, uuid) || strings.EqualFold(strings.ReplaceAll(archive.UUID, "-", ""), uuid) {
+			utils.SetRequestArchive(ctx, archive.Name)
+			return archive, true
+		}
+	}
+	return nil, false
+}
+
+func (s *ArchiveService) AllArchives() []*Archive {
+	return s.listArchives(func(*Archive) bool { return true })
+}
+
+func (s *ArchiveService) ListArchives(ctx context.Context) []*Archive {
+	return s.listArchives(func(archive *Archive) bool { return s.visible(ctx, archive) })
+}
+
+func (s *ArchiveService) listArchives(include func(*Archive) bool) []*Archive {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	archives := make([]*Archive, 0, len(s.archives))
 	for _, archive := range s.archives {
-		archives = append(archives, archive)
+		if include(archive) {
+			archives = append(archives, archive)
+		}
 	}
 
 	sort.Slice(archives, func(i, j int) bool {
@@ -197,12 +378,9 @@ func (s *ArchiveService) ListArchives() []*Archive {
 	return archives
 }
 
-func (s *ArchiveService) GetLanguages() []LanguageInfo {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
+func (s *ArchiveService) GetLanguages(ctx context.Context) []LanguageInfo {
 	langMap := make(map[string]string)
-	for _, archive := range s.archives {
+	for _, archive := range s.ListArchives(ctx) {
 		if archive.Metadata.Language != "" {
 			code := archive.Metadata.LanguageCode
 			if code != "MUL" {
@@ -228,12 +406,9 @@ func (s *ArchiveService) GetLanguages() []LanguageInfo {
 	return languages
 }
 
-func (s *ArchiveService) GetCategories() []string {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
+func (s *ArchiveService) GetCategories(ctx context.Context) []string {
 	categoryMap := make(map[string]bool)
-	for _, archive := range s.archives {
+	for _, archive := range s.ListArchives(ctx) {
 		if archive.Metadata.Tags != "" {
 			tags := strings.Split(archive.Metadata.Tags, ";")
 			for _, tag := range tags {
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaetanlhf/ZIMServer/internal/logging"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	SessionCookie = "zimserver_session"
+
+	htpasswdCheckInterval = 2 * time.Second
+)
+
+type PrincipalKind string
+
+const (
+	PrincipalUser  PrincipalKind = "user"
+	PrincipalToken PrincipalKind = "token"
+)
+
+type Principal struct {
+	Name string
+	Kind PrincipalKind
+}
+
+type principalKey struct{}
+
+type AuthService struct {
+	htpasswdFile  string
+	users         map[string]string
+	modTime       time.Time
+	checked       time.Time
+	tokens        map[string]string
+	sessionSecret []byte
+	sessionTTL    time.Duration
+	public        []string
+	mu            sync.Mutex
+}
+
+func NewAuthService(htpasswdFile string, tokens map[string]string, sessionSecret []byte, sessionTTL time.Duration, public []string) (*AuthService, error) {
+	s := &AuthService{
+		htpasswdFile:  htpasswdFile,
+		users:         make(map[string]string),
+		tokens:        make(map[string]string),
+		sessionSecret: sessionSecret,
+		sessionTTL:    sessionTTL,
+		public:        public,
+	}
+
+	for name, token := range tokens {
+		s.tokens[token] = name
+	}
+
+	if htpasswdFile != "" {
+		if err := s.loadHtpasswd(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (s *AuthService) HasPasswords() bool {
+	return s.htpasswdFile != ""
+}
+
+func (s *AuthService) IsPublic(path string) bool {
+	for _, prefix := range s.public {
+		if path == prefix || (strings.HasSuffix(prefix, "/") && strings.HasPrefix(path, prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *AuthService) Authenticate(r *http.Request) (*Principal, bool) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if token, found := strings.CutPrefix(header, "Bearer "); found {
+			return s.checkToken(strings.TrimSpace(token))
+		}
+		if username, password, ok := r.BasicAuth(); ok {
+			if s.CheckPassword(username, password) {
+				return &Principal{Name: username, Kind: PrincipalUser}, true
+			}
+			return nil, false
+		}
+	}
+
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		if username, ok := s.verifySession(cookie.Value); ok {
+			return &Principal{Name: username, Kind: PrincipalUser}, true
+		}
+	}
+
+	return nil, false
+}
+
+func (s *AuthService) CheckPassword(username, password string) bool {
+	s.mu.Lock()
+	s.refreshHtpasswd()
+	hash, exists := s.users[username]
+	s.mu.Unlock()
+
+	if !exists {
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func (s *AuthService) NewSession(username string) *http.Cookie {
+	expires := time.Now().Add(s.sessionTTL)
+	payload := base64.RawURLEncoding.EncodeToString([]byte(username + "|" + strconv.FormatInt(expires.Unix(), 10)))
+
+	return &http.Cookie{
+		Name:     SessionCookie,
+		Value:    payload + "." + s.sign(payload),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (s *AuthService) verifySession(value string) (string, bool) {
+	payload, signature, found := strings.Cut(value, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return "", false
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", false
+	}
+
+	username, expiresStr, found := strings.Cut(string(data), "|")
+	if !found {
+		return "", false
+	}
+
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return "", false
+	}
+
+	s.mu.Lock()
+	s.refreshHtpasswd()
+	_, exists := s.users[username]
+	s.mu.Unlock()
+
+	return username, exists
+}
+
+func (s *AuthService) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.sessionSecret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *AuthService) checkToken(token string) (*Principal, bool) {
+	for candidate, name := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			return &Principal{Name: name, Kind: PrincipalToken}, true
+		}
+	}
+	return nil, false
+}
+
+func (s *AuthService) refreshHtpasswd() {
+	if s.htpasswdFile == "" || time.Since(s.checked) < htpasswdCheckInterval {
+		return
+	}
+	s.checked = time.Now()
+
+	info, err := os.Stat(s.htpasswdFile)
+	if err != nil || !info.ModTime().After(s.modTime) {
+		return
+	}
+
+	if err := s.loadHtpasswd(); err != nil {
+		slog.Warn("Failed to reload htpasswd file, keeping current users", "file", s.htpasswdFile, "error", err)
+		return
+	}
+
+	logging.Success("Reloaded htpasswd file", "file", s.htpasswdFile, "users", len(s.users))
+}
+
+func (s *AuthService) loadHtpasswd() error {
+	file, err := os.Open(s.htpasswdFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		username, hash, found := strings.Cut(line, ":")
+		if !found || username == "" {
+			return fmt.Errorf("%s:%d: malformed entry", s.htpasswdFile, lineNumber)
+		}
+
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			slog.Warn("Skipping htpasswd user: only bcrypt hashes are supported", "user", username, "file", s.htpasswdFile)
+			continue
+		}
+
+		users[username] = hash
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	s.users = users
+	s.modTime = info.ModTime()
+	s.checked = time.Now()
+
+	return nil
+}
+
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
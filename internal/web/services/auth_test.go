@@ -0,0 +1,132 @@
+package services
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoadHtpasswd(t *testing.T) {
+	hash := bcryptHash(t, "secret")
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{"single user", "alice:" + hash + "\n", []string{"alice"}, false},
+		{"comments and blank lines", "# users\n\nalice:" + hash + "\n  \nbob:" + hash + "\n", []string{"alice", "bob"}, false},
+		{"surrounding spaces", "  alice:" + hash + "  \n", []string{"alice"}, false},
+		{"non-bcrypt hash skipped", "alice:" + hash + "\nbob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\ncarol:plain\n", []string{"alice"}, false},
+		{"missing separator", "alice\n", nil, true},
+		{"empty username", ":" + hash + "\n", nil, true},
+		{"empty file", "", []string{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := writeHtpasswd(t, tt.content)
+			s, err := NewAuthService(file, nil, []byte("0123456789abcdef"), time.Hour, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(s.users) != len(tt.want) {
+				t.Fatalf("loaded %d users, want %d", len(s.users), len(tt.want))
+			}
+			for _, name := range tt.want {
+				if !s.CheckPassword(name, "secret") {
+					t.Errorf("CheckPassword(%q) failed", name)
+				}
+			}
+		})
+	}
+}
+
+func TestSessionCookie(t *testing.T) {
+	file := writeHtpasswd(t, "alice:"+bcryptHash(t, "secret")+"\n")
+	s, err := NewAuthService(file, nil, []byte("0123456789abcdef"), time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewAuthService(file, nil, []byte("fedcba9876543210"), time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := s.NewSession("alice").Value
+	payload, signature, _ := strings.Cut(valid, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte("mallory|" + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)))
+	expired := base64.RawURLEncoding.EncodeToString([]byte("alice|" + strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)))
+	unknown := base64.RawURLEncoding.EncodeToString([]byte("bob|" + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)))
+	malformed := base64.RawURLEncoding.EncodeToString([]byte("alice"))
+
+	tests := []struct {
+		name     string
+		value    string
+		wantUser string
+		wantOK   bool
+	}{
+		{"valid", valid, "alice", true},
+		{"signed with another secret", other.NewSession("alice").Value, "", false},
+		{"payload swapped", forged + "." + signature, "", false},
+		{"signature tampered", payload + "." + strings.ToUpper(signature), "", false},
+		{"missing signature", payload, "", false},
+		{"expired", expired + "." + s.sign(expired), "", false},
+		{"user no longer in htpasswd", unknown + "." + s.sign(unknown), "bob", false},
+		{"no expiry", malformed + "." + s.sign(malformed), "", false},
+		{"empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.AddCookie(&http.Cookie{Name: SessionCookie, Value: tt.value})
+
+			principal, ok := s.Authenticate(r)
+			if ok != tt.wantOK {
+				t.Fatalf("Authenticate ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && (principal.Name != tt.wantUser || principal.Kind != PrincipalUser) {
+				t.Errorf("principal = %+v, want user %q", principal, tt.wantUser)
+			}
+
+			if user, _ := s.verifySession(tt.value); user != tt.wantUser {
+				t.Errorf("verifySession user = %q, want %q", user, tt.wantUser)
+			}
+		})
+	}
+}
+
+func bcryptHash(t *testing.T, password string) string {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(hash)
+}
+
+func writeHtpasswd(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "users.htpasswd")
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
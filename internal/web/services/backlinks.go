@@ -0,0 +1,161 @@
+package services
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
+	zimreader "github.com/gaetanlhf/ZIMServer/internal/zim/reader"
+)
+
+type BacklinkIndex struct {
+	ready   bool
+	targets []uint32
+	offsets []uint32
+	sources []uint32
+	mu      sync.RWMutex
+}
+
+type BacklinkService struct {
+	indexes map[string]*BacklinkIndex
+	mu      sync.Mutex
+}
+
+func NewBacklinkService() *BacklinkService {
+	return &BacklinkService{
+		indexes: make(map[string]*BacklinkIndex),
+	}
+}
+
+func (s *BacklinkService) Build(archive *Archive) *BacklinkIndex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if idx, exists := s.indexes[archive.UUID]; exists {
+		return idx
+	}
+
+	idx := &BacklinkIndex{}
+	s.indexes[archive.UUID] = idx
+
+	go idx.build(archive)
+
+	return idx
+}
+
+func (s *BacklinkService) GetIndex(uuid string) (*BacklinkIndex, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx, exists := s.indexes[uuid]
+	return idx, exists
+}
+
+func (s *BacklinkService) Forget(uuid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.indexes, uuid)
+}
+
+func (idx *BacklinkIndex) build(archive *Archive) {
+	start := time.Now()
+	reader := archive.Reader
+	count := reader.GetHeader().EntryCount
+
+	edges := make([]uint64, 0)
+
+	for i := uint32(0); i < count; i++ {
+		entry, err := reader.GetEntryByIndex(i)
+		if err != nil || entry.IsRedirect() || entry.GetNamespace() != zimreader.NamespaceContent {
+			continue
+		}
+
+		mimeType, err := reader.GetMimeType(entry)
+		if err != nil || !strings.HasPrefix(mimeType, "text/html") {
+			continue
+		}
+
+		content, err := reader.GetContent(entry)
+		if err != nil {
+			continue
+		}
+
+		for _, link := range utils.ExtractLinks(content) {
+			if link.Tag != "a" && link.Tag != "area" {
+				continue
+			}
+
+			targetPath, ok := utils.ResolveEntryPath(entry.GetPath(), link.URL)
+			if !ok {
+				continue
+			}
+
+			target, err := reader.GetEntryIndexByPath(string(zimreader.NamespaceContent) + targetPath)
+			if err != nil {
+				continue
+			}
+
+			target, err = reader.ResolveRedirectIndex(target)
+			if err != nil || target == i {
+				continue
+			}
+
+			edges = append(edges, uint64(target)<<32|uint64(i))
+		}
+	}
+
+	sort.Slice(edges, func(a, b int) bool { return edges[a] < edges[b] })
+
+	targets := make([]uint32, 0)
+	offsets := make([]uint32, 0)
+	sources := make([]uint32, 0, len(edges))
+
+	var previous uint64
+	for n, edge := range edges {
+		if n > 0 && edge == previous {
+			continue
+		}
+		previous = edge
+
+		target := uint32(edge >> 32)
+		if len(targets) == 0 || targets[len(targets)-1] != target {
+			targets = append(targets, target)
+			offsets = append(offsets, uint32(len(sources)))
+		}
+		sources = append(sources, uint32(edge))
+	}
+	offsets = append(offsets, uint32(len(sources)))
+
+	idx.mu.Lock()
+	idx.targets = targets
+	idx.offsets = offsets
+	idx.sources = sources
+	idx.ready = true
+	idx.mu.Unlock()
+
+	slog.Info("Backlink index built", "archive", archive.Name, "links", len(sources), "entries", len(targets),
+		"duration", time.Since(start).Round(time.Millisecond))
+}
+
+func (idx *BacklinkIndex) Ready() bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.ready
+}
+
+func (idx *BacklinkIndex) Sources(target uint32) []uint32 {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	pos := sort.Search(len(idx.targets), func(i int) bool {
+		return idx.targets[i] >= target
+	})
+
+	if pos >= len(idx.targets) || idx.targets[pos] != target {
+		return nil
+	}
+
+	return idx.sources[idx.offsets[pos]:idx.offsets[pos+1]]
+}
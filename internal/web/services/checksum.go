@@ -0,0 +1,134 @@
+package services
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ChecksumAlgorithm string
+
+const (
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+	ChecksumMD5    ChecksumAlgorithm = "md5"
+)
+
+type checksum struct {
+	once  sync.Once
+	value string
+	err   error
+}
+
+type ChecksumService struct {
+	checksums map[string]*checksum
+	mu        sync.Mutex
+}
+
+func NewChecksumService() *ChecksumService {
+	return &ChecksumService{
+		checksums: make(map[string]*checksum),
+	}
+}
+
+func ParseChecksumAlgorithm(ext string) (ChecksumAlgorithm, bool) {
+	switch ChecksumAlgorithm(strings.ToLower(ext)) {
+	case ChecksumSHA256:
+		return ChecksumSHA256, true
+	case ChecksumMD5:
+		return ChecksumMD5, true
+	}
+	return "", false
+}
+
+func (s *ChecksumService) Get(archive *Archive, algorithm ChecksumAlgorithm) (string, error) {
+	if value, ok := readSidecar(archive.Path + "." + string(algorithm)); ok {
+		return value, nil
+	}
+
+	key := archive.UUID + ":" + string(algorithm)
+
+	s.mu.Lock()
+	c, exists := s.checksums[key]
+	if !exists {
+		c = &checksum{}
+		s.checksums[key] = c
+	}
+	s.mu.Unlock()
+
+	c.once.Do(func() {
+		start := time.Now()
+		c.value, c.err = computeChecksum(archive.Path, algorithm)
+		if c.err == nil {
+			slog.Info("Checksum computed", "archive", archive.Name, "algorithm", algorithm, "duration", time.Since(start).Round(time.Millisecond))
+		}
+	})
+
+	if c.err != nil {
+		s.mu.Lock()
+		delete(s.checksums, key)
+		s.mu.Unlock()
+	}
+
+	return c.value, c.err
+}
+
+func (s *ChecksumService) Forget(uuid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.checksums {
+		if strings.HasPrefix(key, uuid+":") {
+			delete(s.checksums, key)
+		}
+	}
+}
+
+func readSidecar(path string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	if _, err := hex.DecodeString(fields[0]); err != nil {
+		return "", false
+	}
+
+	return strings.ToLower(fields[0]), true
+}
+
+func computeChecksum(path string, algorithm ChecksumAlgorithm) (string, error) {
+	var h hash.Hash
+	switch algorithm {
+	case ChecksumSHA256:
+		h = sha256.New()
+	case ChecksumMD5:
+		h = md5.New()
+	default:
+		return "", fmt.Errorf("unsupported checksum algorithm: %s", algorithm)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
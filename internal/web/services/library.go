@@ -0,0 +1,220 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
+)
+
+const libraryVersion = "20110515"
+
+type Library struct {
+	XMLName xml.Name      `xml:"library"`
+	Version string        `xml:"version,attr,omitempty"`
+	Books   []LibraryBook `xml:"book"`
+}
+
+type LibraryBook struct {
+	ID              string `xml:"id,attr"`
+	Path            string `xml:"path,attr,omitempty"`
+	URL             string `xml:"url,attr,omitempty"`
+	Title           string `xml:"title,attr,omitempty"`
+	Description     string `xml:"description,attr,omitempty"`
+	Language        string `xml:"language,attr,omitempty"`
+	Creator         string `xml:"creator,attr,omitempty"`
+	Publisher       string `xml:"publisher,attr,omitempty"`
+	Name            string `xml:"name,attr,omitempty"`
+	Flavour         string `xml:"flavour,attr,omitempty"`
+	Category        string `xml:"category,attr,omitempty"`
+	Tags            string `xml:"tags,attr,omitempty"`
+	Date            string `xml:"date,attr,omitempty"`
+	ArticleCount    string `xml:"articleCount,attr,omitempty"`
+	MediaCount      string `xml:"mediaCount,attr,omitempty"`
+	Size            string `xml:"size,attr,omitempty"`
+	FaviconMimeType string `xml:"faviconMimeType,attr,omitempty"`
+	Favicon         string `xml:"favicon,attr,omitempty"`
+}
+
+func ReadLibrary(path string) (*Library, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var library Library
+	if err := xml.Unmarshal(data, &library); err != nil {
+		return nil, fmt.Errorf("invalid library file: %w", err)
+	}
+
+	baseDir := filepath.Dir(path)
+	for i := range library.Books {
+		book := &library.Books[i]
+		if book.Path != "" && !filepath.IsAbs(book.Path) {
+			book.Path = filepath.Join(baseDir, book.Path)
+		}
+	}
+
+	return &library, nil
+}
+
+func (l *Library) Paths() []string {
+	paths := make([]string, 0, len(l.Books))
+	for _, book := range l.Books {
+		if book.Path != "" {
+			paths = append(paths, book.Path)
+		}
+	}
+	return paths
+}
+
+func WriteLibrary(w io.Writer, books []LibraryBook) error {
+	library := Library{
+		Version: libraryVersion,
+		Books:   books,
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(library); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (s *ArchiveService) SetLibrary(library *Library) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.library = make(map[string]LibraryBook)
+	for _, book := range library.Books {
+		if book.Path == "" {
+			continue
+		}
+		if absPath, err := filepath.Abs(book.Path); err == nil {
+			s.library[absPath] = book
+		}
+	}
+}
+
+func (s *ArchiveService) libraryBook(path string) (LibraryBook, bool) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return LibraryBook{}, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	book, exists := s.library[absPath]
+	return book, exists
+}
+
+func (b LibraryBook) apply(archive *Archive) {
+	metadata := &archive.Metadata
+
+	overrides := []struct {
+		value string
+		field *string
+	}{
+		{b.Title, &metadata.Title},
+		{b.Description, &metadata.Description},
+		{b.Language, &metadata.Language},
+		{b.Creator, &metadata.Creator},
+		{b.Publisher, &metadata.Publisher},
+		{b.Name, &metadata.Name},
+		{b.Flavour, &metadata.Flavour},
+		{b.Tags, &metadata.Tags},
+		{b.Date, &metadata.Date},
+	}
+
+	for _, override := range overrides {
+		if override.value != "" {
+			*override.field = override.value
+		}
+	}
+
+	metadata.LanguageCode = languageCode(metadata.Language)
+	metadata.Category = extractMainCategory(metadata.Tags)
+	if b.Category != "" {
+		metadata.Category = utils.CapitalizeFirst(b.Category)
+	}
+
+	if count, err := strconv.Atoi(b.ArticleCount); err == nil {
+		archive.ArticleCount = count
+	}
+	if count, err := strconv.Atoi(b.MediaCount); err == nil {
+		archive.MediaCount = count
+	}
+}
+
+func (s *ArchiveService) NewLibraryBook(archive *Archive, baseDir string) LibraryBook {
+	metadata := archive.Metadata
+
+	path := archive.Path
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+		if baseDir != "" {
+			if relPath, err := filepath.Rel(baseDir, absPath); err == nil && !strings.HasPrefix(relPath, "..") {
+				path = relPath
+			}
+		}
+	}
+
+	book := LibraryBook{
+		ID:           archive.UUID,
+		Path:         path,
+		Title:        metadata.Title,
+		Description:  metadata.Description,
+		Language:     metadata.Language,
+		Creator:      metadata.Creator,
+		Publisher:    metadata.Publisher,
+		Name:         metadata.Name,
+		Flavour:      metadata.Flavour,
+		Category:     strings.ToLower(metadata.Category),
+		Tags:         metadata.Tags,
+		Date:         metadata.Date,
+		ArticleCount: strconv.Itoa(archive.ArticleCount),
+		MediaCount:   strconv.Itoa(archive.MediaCount),
+		Size:         strconv.FormatInt(archive.Size/1024, 10),
+	}
+
+	if illustration, found := s.FindIllustration(archive, 48, 1); found {
+		if content, err := archive.Reader.GetContent(illustration.Entry); err == nil {
+			mimeType, _ := archive.Reader.GetMimeType(illustration.Entry)
+			if mimeType == "" {
+				mimeType = "image/png"
+			}
+			book.FaviconMimeType = mimeType
+			book.Favicon = utils.EncodeBase64(content)
+		}
+	}
+
+	return book
+}
+
+func (s *ArchiveService) ExportLibrary(baseDir string) []LibraryBook {
+	archives := s.AllArchives()
+	books := make([]LibraryBook, 0, len(archives))
+
+	for _, archive := range archives {
+		books = append(books, s.NewLibraryBook(archive, baseDir))
+	}
+
+	sort.Slice(books, func(i, j int) bool {
+		return books[i].Path < books[j].Path
+	})
+
+	return books
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
+	zimreader "github.com/gaetanlhf/ZIMServer/internal/zim/reader"
+)
+
+type LinkReport struct {
+	Articles      int              `json:"articles"`
+	Links         int              `json:"links"`
+	Missing       int              `json:"missing"`
+	RedirectLoops int              `json:"redirectLoops"`
+	External      int              `json:"external"`
+	Duration      string           `json:"duration"`
+	Pages         []PageLinkReport `json:"pages"`
+}
+
+type PageLinkReport struct {
+	Path          string   `json:"path"`
+	Title         string   `json:"title"`
+	Links         int      `json:"links"`
+	Missing       int      `json:"missing"`
+	RedirectLoops int      `json:"redirectLoops"`
+	External      int      `json:"external"`
+	MissingLinks  []string `json:"missingLinks,omitempty"`
+	LoopLinks     []string `json:"loopLinks,omitempty"`
+}
+
+const (
+	linkCheckRefreshInterval = 10 * time.Minute
+	linkCheckBusyRetry       = 30 * time.Second
+)
+
+type linkCheck struct {
+	running  bool
+	report   *LinkReport
+	finished time.Time
+}
+
+type LinkCheckService struct {
+	checks  map[string]*linkCheck
+	running bool
+	mu      sync.Mutex
+}
+
+func NewLinkCheckService() *LinkCheckService {
+	return &LinkCheckService{
+		checks: make(map[string]*linkCheck),
+	}
+}
+
+func (s *LinkCheckService) GetReport(archive *Archive, refresh bool) (*LinkReport, bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	check, exists := s.checks[archive.UUID]
+	if exists && (check.running || !refresh) {
+		return check.report, check.running, 0
+	}
+	if exists {
+		if wait := linkCheckRefreshInterval - time.Since(check.finished); wait > 0 {
+			return check.report, false, wait
+		}
+	}
+	if s.running {
+		if exists {
+			return check.report, false, linkCheckBusyRetry
+		}
+		return nil, false, linkCheckBusyRetry
+	}
+
+	if !exists {
+		check = &linkCheck{}
+		s.checks[archive.UUID] = check
+	}
+	check.running = true
+	s.running = true
+
+	go func() {
+		report := CheckLinks(archive.Reader)
+		slog.Info("Link check finished", "archive", archive.Name, "links", report.Links, "missing", report.Missing,
+			"redirect_loops", report.RedirectLoops, "external", report.External, "duration", report.Duration)
+
+		s.mu.Lock()
+		check.report = report
+		check.running = false
+		check.finished = time.Now()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	return check.report, true, 0
+}
+
+func (s *LinkCheckService) Forget(uuid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.checks, uuid)
+}
+
+func CheckLinks(reader *zimreader.ZIMReader) *LinkReport {
+	start := time.Now()
+	count := reader.GetHeader().EntryCount
+
+	report := &LinkReport{
+		Pages: make([]PageLinkReport, 0),
+	}
+
+	for i := uint32(0); i < count; i++ {
+		entry, err := reader.GetEntryByIndex(i)
+		if err != nil || entry.IsRedirect() || entry.GetNamespace() != zimreader.NamespaceContent {
+			continue
+		}
+
+		mimeType, err := reader.GetMimeType(entry)
+		if err != nil || !strings.HasPrefix(mimeType, "text/html") {
+			continue
+		}
+
+		content, err := reader.GetContent(entry)
+		if err != nil {
+			continue
+		}
+
+		page := checkPageLinks(reader, entry, content)
+
+		report.Articles++
+		report.Links += page.Links
+		report.Missing += page.Missing
+		report.RedirectLoops += page.RedirectLoops
+		report.External += page.External
+
+		if page.Missing > 0 || page.RedirectLoops > 0 || page.External > 0 {
+			report.Pages = append(report.Pages, page)
+		}
+	}
+
+	sort.SliceStable(report.Pages, func(i, j int) bool {
+		return report.Pages[i].Missing+report.Pages[i].RedirectLoops > report.Pages[j].Missing+report.Pages[j].RedirectLoops
+	})
+
+	report.Duration = time.Since(start).Round(time.Millisecond).String()
+
+	return report
+}
+
+func checkPageLinks(reader *zimreader.ZIMReader, entry zimreader.DirectoryEntry, content []byte) PageLinkReport {
+	page := PageLinkReport{
+		Path:  entry.GetPath(),
+		Title: entry.GetTitle(),
+	}
+
+	checked := make(map[string]bool)
+
+	for _, link := range utils.ExtractLinks(content) {
+		ref := strings.TrimSpace(link.URL)
+		if ref == "" || strings.HasPrefix(ref, "#") {
+			continue
+		}
+
+		u, err := url.Parse(ref)
+		if err != nil {
+			page.Links++
+			page.Missing++
+			page.MissingLinks = append(page.MissingLinks, ref)
+			continue
+		}
+
+		if u.Scheme != "" || u.Host != "" {
+			switch strings.ToLower(u.Scheme) {
+			case "http", "https", "ftp", "":
+				page.Links++
+				page.External++
+			}
+			continue
+		}
+
+		if u.Path == "" {
+			continue
+		}
+
+		page.Links++
+
+		targetPath, ok := utils.ResolveEntryPath(page.Path, ref)
+		if !ok {
+			page.Missing++
+			page.MissingLinks = append(page.MissingLinks, ref)
+			continue
+		}
+
+		if checked[targetPath] {
+			continue
+		}
+		checked[targetPath] = true
+
+		target, err := reader.GetEntryByURL(zimreader.NamespaceContent, targetPath)
+		if err != nil {
+			page.Missing++
+			page.MissingLinks = append(page.MissingLinks, targetPath)
+			continue
+		}
+
+		if _, err := reader.ResolveRedirect(target); err != nil {
+			page.RedirectLoops++
+			page.LoopLinks = append(page.LoopLinks, targetPath)
+		}
+	}
+
+	return page
+}
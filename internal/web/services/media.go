@@ -0,0 +1,174 @@
+package services
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	zimreader "github.com/gaetanlhf/ZIMServer/internal/zim/reader"
+)
+
+type MediaType string
+
+const (
+	MediaImage MediaType = "image"
+	MediaAudio MediaType = "audio"
+	MediaVideo MediaType = "video"
+	MediaPDF   MediaType = "pdf"
+)
+
+var MediaTypes = []MediaType{MediaImage, MediaAudio, MediaVideo, MediaPDF}
+
+type MediaItem struct {
+	Title    string
+	Path     string
+	MimeType string
+}
+
+type MediaIndex struct {
+	ready   bool
+	entries map[MediaType][]uint32
+	mu      sync.RWMutex
+}
+
+type MediaService struct {
+	indexes map[string]*MediaIndex
+	mu      sync.Mutex
+}
+
+func NewMediaService() *MediaService {
+	return &MediaService{
+		indexes: make(map[string]*MediaIndex),
+	}
+}
+
+func (s *MediaService) GetIndex(archive *Archive) *MediaIndex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if idx, exists := s.indexes[archive.UUID]; exists {
+		return idx
+	}
+
+	idx := &MediaIndex{
+		entries: make(map[MediaType][]uint32),
+	}
+	s.indexes[archive.UUID] = idx
+
+	go idx.build(archive)
+
+	return idx
+}
+
+func (s *MediaService) Forget(uuid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.indexes, uuid)
+}
+
+func (idx *MediaIndex) build(archive *Archive) {
+	reader := archive.Reader
+	count := reader.GetHeader().EntryCount
+	entries := make(map[MediaType][]uint32)
+
+	for i := uint32(0); i < count; i++ {
+		entry, err := reader.GetEntryByIndex(i)
+		if err != nil || entry.IsRedirect() || entry.GetNamespace() != zimreader.NamespaceContent {
+			continue
+		}
+
+		mimeType, err := reader.GetMimeType(entry)
+		if err != nil {
+			continue
+		}
+
+		if mediaType, ok := ClassifyMedia(mimeType); ok {
+			entries[mediaType] = append(entries[mediaType], i)
+		}
+	}
+
+	idx.mu.Lock()
+	idx.entries = entries
+	idx.ready = true
+	idx.mu.Unlock()
+
+	slog.Info("Media index built", "archive", archive.Name, "images", len(entries[MediaImage]), "audio", len(entries[MediaAudio]),
+		"video", len(entries[MediaVideo]), "pdf", len(entries[MediaPDF]))
+}
+
+func ClassifyMedia(mimeType string) (MediaType, bool) {
+	mimeType = strings.ToLower(strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0]))
+
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return MediaImage, true
+	case strings.HasPrefix(mimeType, "audio/"):
+		return MediaAudio, true
+	case strings.HasPrefix(mimeType, "video/"):
+		return MediaVideo, true
+	case mimeType == "application/pdf":
+		return MediaPDF, true
+	default:
+		return "", false
+	}
+}
+
+func ParseMediaType(s string) (MediaType, bool) {
+	for _, mediaType := range MediaTypes {
+		if string(mediaType) == strings.ToLower(s) {
+			return mediaType, true
+		}
+	}
+	return "", false
+}
+
+func (idx *MediaIndex) Ready() bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.ready
+}
+
+func (idx *MediaIndex) Counts() map[MediaType]int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	counts := make(map[MediaType]int, len(MediaTypes))
+	for _, mediaType := range MediaTypes {
+		counts[mediaType] = len(idx.entries[mediaType])
+	}
+	return counts
+}
+
+func (idx *MediaIndex) List(reader *zimreader.ZIMReader, mediaType MediaType, offset, limit int) ([]MediaItem, int) {
+	idx.mu.RLock()
+	entries := idx.entries[mediaType]
+	idx.mu.RUnlock()
+
+	total := len(entries)
+	if offset < 0 || offset >= total {
+		return []MediaItem{}, total
+	}
+
+	end := total
+	if limit > 0 && limit < total-offset {
+		end = offset + limit
+	}
+
+	items := make([]MediaItem, 0, end-offset)
+	for _, entryIndex := range entries[offset:end] {
+		entry, err := reader.GetEntryByIndex(entryIndex)
+		if err != nil {
+			continue
+		}
+
+		mimeType, _ := reader.GetMimeType(entry)
+
+		items = append(items, MediaItem{
+			Title:    entry.GetTitle(),
+			Path:     entry.GetPath(),
+			MimeType: mimeType,
+		})
+	}
+
+	return items, total
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	zimreader "github.com/gaetanlhf/ZIMServer/internal/zim/reader"
+)
+
+type MetadataEntry struct {
+	Key      string
+	MimeType string
+	Value    string
+	IsText   bool
+	Size     int
+}
+
+type Illustration struct {
+	Width  int
+	Height int
+	Scale  int
+	Entry  zimreader.DirectoryEntry
+}
+
+func (s *ArchiveService) ListMetadata(archive *Archive) ([]MetadataEntry, error) {
+	entries, err := archive.Reader.ListEntriesByNamespace(zimreader.NamespaceMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make([]MetadataEntry, 0, len(entries))
+	for _, entry := range entries {
+		content, err := archive.Reader.GetContent(entry)
+		if err != nil {
+			continue
+		}
+
+		mimeType, _ := archive.Reader.GetMimeType(entry)
+
+		item := MetadataEntry{
+			Key:      entry.GetPath(),
+			MimeType: mimeType,
+			Size:     len(content),
+		}
+
+		if isTextMetadata(mimeType, content) {
+			item.IsText = true
+			item.Value = string(content)
+		}
+
+		metadata = append(metadata, item)
+	}
+
+	return metadata, nil
+}
+
+func isTextMetadata(mimeType string, content []byte) bool {
+	if strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	if mimeType != "" {
+		return false
+	}
+	return utf8.Valid(content)
+}
+
+func (s *ArchiveService) ListIllustrations(archive *Archive) []Illustration {
+	return archive.Illustrations
+}
+
+func listIllustrations(reader *zimreader.ZIMReader) []Illustration {
+	entries, err := reader.ListEntriesByNamespace(zimreader.NamespaceMetadata)
+	if err != nil {
+		return nil
+	}
+
+	illustrations := make([]Illustration, 0)
+	for _, entry := range entries {
+		var width, height, scale int
+		if _, err := fmt.Sscanf(entry.GetPath(), "Illustration_%dx%d@%d", &width, &height, &scale); err != nil {
+			continue
+		}
+
+		illustrations = append(illustrations, Illustration{
+			Width:  width,
+			Height: height,
+			Scale:  scale,
+			Entry:  entry,
+		})
+	}
+
+	sort.Slice(illustrations, func(i, j int) bool {
+		return illustrations[i].Width*illustrations[i].Scale < illustrations[j].Width*illustrations[j].Scale
+	})
+
+	return illustrations
+}
+
+func (s *ArchiveService) FindIllustration(archive *Archive, size, scale int) (*Illustration, bool) {
+	illustrations := s.ListIllustrations(archive)
+	if len(illustrations) == 0 {
+		return nil, false
+	}
+
+	for i := range illustrations {
+		if illustrations[i].Width == size && illustrations[i].Scale == scale {
+			return &illustrations[i], true
+		}
+	}
+
+	pixels := size * scale
+	for i := range illustrations {
+		if illustrations[i].Width*illustrations[i].Scale >= pixels {
+			return &illustrations[i], true
+		}
+	}
+
+	return &illustrations[len(illustrations)-1], true
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
+)
+
+type ArchiveQuery struct {
+	Name     string
+	Language string
+	Category string
+	Tag      string
+	Query    string
+	Sort     string
+	Desc     bool
+	Offset   int
+	Limit    int
+}
+
+func (s *ArchiveService) QueryArchives(ctx context.Context, query ArchiveQuery) ([]*Archive, int) {
+	matches := make([]*Archive, 0)
+	for _, archive := range s.ListArchives(ctx) {
+		if archive.Matches(query) {
+			matches = append(matches, archive)
+		}
+	}
+
+	sortArchives(matches, query.Sort, query.Desc)
+
+	total := len(matches)
+	if query.Offset < 0 || query.Offset >= total {
+		return []*Archive{}, total
+	}
+
+	end := total
+	if query.Limit > 0 && query.Limit < total-query.Offset {
+		end = query.Offset + query.Limit
+	}
+
+	return matches[query.Offset:end], total
+}
+
+func (a *Archive) Matches(query ArchiveQuery) bool {
+	metadata := a.Metadata
+
+	if query.Name != "" && a.Name != query.Name && metadata.Name != query.Name {
+		return false
+	}
+
+	if query.Language != "" {
+		language := strings.ToLower(query.Language)
+		matchesLanguage := metadata.LanguageCode == "MUL" ||
+			strings.ToLower(metadata.LanguageCode) == language
+		for _, code := range strings.FieldsFunc(metadata.Language, func(r rune) bool { return r == ',' || r == ';' }) {
+			if strings.ToLower(strings.TrimSpace(code)) == language {
+				matchesLanguage = true
+			}
+		}
+		if !matchesLanguage {
+			return false
+		}
+	}
+
+	if query.Category != "" {
+		category := strings.ToLower(query.Category)
+		if strings.ToLower(metadata.Category) != category && !a.HasTag(category) && !a.HasTag("_category:"+category) {
+			return false
+		}
+	}
+
+	if query.Tag != "" && !a.HasTag(query.Tag) {
+		return false
+	}
+
+	if query.Query != "" {
+		q := strings.ToLower(query.Query)
+		if !strings.Contains(strings.ToLower(metadata.Title), q) &&
+			!strings.Contains(strings.ToLower(metadata.Description), q) &&
+			!strings.Contains(strings.ToLower(a.Name), q) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a *Archive) Tags() []string {
+	tags := make([]string, 0)
+	for _, tag := range strings.Split(a.Metadata.Tags, ";") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func (a *Archive) FormattedSize() string {
+	return utils.FormatSize(a.Size)
+}
+
+func (a *Archive) HasTag(tag string) bool {
+	for _, t := range a.Tags() {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortArchives(archives []*Archive, field string, desc bool) {
+	less := func(i, j int) bool {
+		a, b := archives[i], archives[j]
+		switch field {
+		case "name":
+			return a.Name < b.Name
+		case "date":
+			return a.Metadata.Date < b.Metadata.Date
+		case "size":
+			return a.Size < b.Size
+		case "articles":
+			return a.ArticleCount < b.ArticleCount
+		case "media":
+			return a.MediaCount < b.MediaCount
+		default:
+			return strings.ToLower(a.Metadata.Title) < strings.ToLower(b.Metadata.Title)
+		}
+	}
+
+	if desc {
+		sort.SliceStable(archives, func(i, j int) bool { return less(j, i) })
+	} else {
+		sort.SliceStable(archives, less)
+	}
+}
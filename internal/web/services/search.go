@@ -3,10 +3,12 @@ package services
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
+
+	"github.com/gaetanlhf/ZIMServer/internal/metrics"
 )
 
 type SearchService struct{}
@@ -58,6 +60,7 @@ func (s *SearchService) HandleSearch(w http.ResponseWriter, r *http.Request, arc
 	start := time.Now()
 	results, err := archive.IndexMgr.Search(query, maxResults)
 	elapsed := time.Since(start)
+	metrics.ObserveSearch(archive.Name, "search", elapsed)
 
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Search failed: %v", err), http.StatusInternalServerError)
@@ -86,5 +89,5 @@ func (s *SearchService) HandleSearch(w http.ResponseWriter, r *http.Request, arc
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 
-	log.Printf("Search [%s]: '%s' -> %d results in %s", archive.Name, query, len(results), elapsed)
+	slog.Debug("Search", "archive", archive.Name, "query", query, "results", len(results), "duration", elapsed)
 }
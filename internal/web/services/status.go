@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ArchiveState string
+
+const (
+	StateDiscovered  ArchiveState = "discovered"
+	StateStabilising ArchiveState = "stabilising"
+	StateLoading     ArchiveState = "loading"
+	StateLoaded      ArchiveState = "loaded"
+	StateFailed      ArchiveState = "failed"
+	StateUnloading   ArchiveState = "unloading"
+)
+
+type ArchiveStatus struct {
+	Name  string
+	Path  string
+	State ArchiveState
+	Error string
+	Since time.Time
+}
+
+func ArchiveName(path string) string {
+	baseName := filepath.Base(path)
+	return strings.TrimSuffix(baseName, filepath.Ext(baseName))
+}
+
+func (s *ArchiveService) SetState(path string, state ArchiveState, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setState(path, state, err)
+}
+
+func (s *ArchiveService) setState(path string, state ArchiveState, err error) {
+	status := &ArchiveStatus{
+		Name:  ArchiveName(path),
+		Path:  path,
+		State: state,
+		Since: time.Now(),
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	s.states[status.Name] = status
+}
+
+func (s *ArchiveService) ForgetState(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := ArchiveName(path)
+	if status, exists := s.states[name]; exists && status.Path == path {
+		delete(s.states, name)
+	}
+}
+
+func (s *ArchiveService) State(name string) ArchiveState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if status, exists := s.states[name]; exists {
+		return status.State
+	}
+	return ""
+}
+
+func (s *ArchiveService) States(ctx context.Context) []ArchiveStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]ArchiveStatus, 0, len(s.states))
+	for name, status := range s.states {
+		if s.access != nil && !s.access.Allows(ctx, name) {
+			continue
+		}
+		statuses = append(statuses, *status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses
+}
+
+func (s *ArchiveService) SetStarted() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = true
+}
+
+func (s *ArchiveService) Ready() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.started && len(s.archives) > 0
+}
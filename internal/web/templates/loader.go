@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gaetanlhf/ZIMServer/html"
+	"github.com/gaetanlhf/ZIMServer/internal/web/utils"
 )
 
 type Templates struct {
@@ -19,28 +20,56 @@ type noListingFS struct {
 
 var timeZero = time.Time{}
 
+var funcs = template.FuncMap{
+	"root": func() string { return "" },
+}
+
 func Load() (*Templates, error) {
 	templates := make(map[string]*template.Template)
 
-	homeTemplate, err := template.ParseFS(html.TemplatesFS, "static/templates/base.html", "static/templates/home.html")
+	homeTemplate, err := template.New("base.html").Funcs(funcs).ParseFS(html.TemplatesFS, "static/templates/base.html", "static/templates/home.html")
 	if err != nil {
 		return nil, err
 	}
 	templates["home"] = homeTemplate
 
-	viewerTemplate, err := template.ParseFS(html.TemplatesFS, "static/templates/base.html", "static/templates/viewer.html")
+	viewerTemplate, err := template.New("base.html").Funcs(funcs).ParseFS(html.TemplatesFS, "static/templates/base.html", "static/templates/viewer.html")
 	if err != nil {
 		return nil, err
 	}
 	templates["viewer"] = viewerTemplate
 
-	catchContentTemplate, err := template.ParseFS(html.TemplatesFS, "static/templates/base.html", "static/templates/catch.html")
+	catchContentTemplate, err := template.New("base.html").Funcs(funcs).ParseFS(html.TemplatesFS, "static/templates/base.html", "static/templates/catch.html")
 	if err != nil {
 		return nil, err
 	}
 	templates["catch"] = catchContentTemplate
 
-	notFoundTemplate, err := template.ParseFS(html.TemplatesFS, "static/templates/base.html", "static/templates/404.html")
+	galleryTemplate, err := template.New("base.html").Funcs(funcs).ParseFS(html.TemplatesFS, "static/templates/base.html", "static/templates/gallery.html")
+	if err != nil {
+		return nil, err
+	}
+	templates["gallery"] = galleryTemplate
+
+	searchTemplate, err := template.New("base.html").Funcs(funcs).ParseFS(html.TemplatesFS, "static/templates/base.html", "static/templates/search.html")
+	if err != nil {
+		return nil, err
+	}
+	templates["search"] = searchTemplate
+
+	readTemplate, err := template.New("base.html").Funcs(funcs).ParseFS(html.TemplatesFS, "static/templates/base.html", "static/templates/read.html")
+	if err != nil {
+		return nil, err
+	}
+	templates["read"] = readTemplate
+
+	loginTemplate, err := template.New("base.html").Funcs(funcs).ParseFS(html.TemplatesFS, "static/templates/base.html", "static/templates/login.html")
+	if err != nil {
+		return nil, err
+	}
+	templates["login"] = loginTemplate
+
+	notFoundTemplate, err := template.New("base.html").Funcs(funcs).ParseFS(html.TemplatesFS, "static/templates/base.html", "static/templates/404.html")
 	if err != nil {
 		return nil, err
 	}
@@ -49,13 +78,23 @@ func Load() (*Templates, error) {
 	return &Templates{templates: templates}, nil
 }
 
-func (t *Templates) Render(w http.ResponseWriter, name string, data interface{}) error {
+func (t *Templates) Render(w http.ResponseWriter, r *http.Request, name string, data interface{}) error {
 	tmpl, exists := t.templates[name]
 	if !exists {
 		return http.ErrNotSupported
 	}
 
-	return tmpl.ExecuteTemplate(w, "base.html", data)
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return err
+	}
+
+	root := utils.URLRoot(r.Context())
+	clone.Funcs(template.FuncMap{
+		"root": func() string { return root },
+	})
+
+	return clone.ExecuteTemplate(w, "base.html", data)
 }
 
 func GetAssetsFS() http.FileSystem {
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	zimreader "github.com/gaetanlhf/ZIMServer/internal/zim/reader"
+)
+
+const (
+	CacheNoCache = "no-cache"
+	CacheShort   = "public, max-age=60"
+	CacheAssets  = "public, max-age=3600"
+)
+
+func ContentCacheControl(maxAge time.Duration, immutable bool) string {
+	if maxAge <= 0 {
+		return CacheNoCache
+	}
+	if immutable {
+		return fmt.Sprintf("public, max-age=%d, immutable", int64(maxAge.Seconds()))
+	}
+	return fmt.Sprintf("public, max-age=%d", int64(maxAge.Seconds()))
+}
+
+func ContentETag(uuid string, entry zimreader.DirectoryEntry, variant string) string {
+	key := string(entry.GetNamespace()) + entry.GetPath()
+	if variant != "" {
+		key += "\x00" + variant
+	}
+	sum := sha1.Sum([]byte(key))
+	return fmt.Sprintf("\"%s-%s\"", strings.ReplaceAll(uuid, "-", ""), hex.EncodeToString(sum[:8]))
+}
+
+func SetContentCacheHeaders(w http.ResponseWriter, etag string, maxAge time.Duration, immutable bool) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", ContentCacheControl(maxAge, immutable))
+}
+
+func RouteCacheControl(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/assets/"):
+		return CacheAssets
+	case strings.HasPrefix(path, "/viewer/"),
+		strings.HasPrefix(path, "/read/"),
+		strings.HasPrefix(path, "/gallery/"),
+		strings.HasPrefix(path, "/catalog/"),
+		path == "/search",
+		path == "/suggest":
+		return CacheShort
+	case strings.HasPrefix(path, "/content/"),
+		strings.HasPrefix(path, "/raw/"):
+		return ""
+	default:
+		return CacheNoCache
+	}
+}
+
+func CacheMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if policy := RouteCacheControl(r.URL.Path); policy != "" {
+			w.Header().Set("Cache-Control", policy)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gaetanlhf/ZIMServer/internal/logging"
+)
+
+const (
+	certCheckInterval  = 2 * time.Second
+	selfSignedValidity = 10 * 365 * 24 * time.Hour
+)
+
+type CertificateReloader struct {
+	certFile string
+	keyFile  string
+	cert     *tls.Certificate
+	modTime  time.Time
+	checked  time.Time
+	mu       sync.Mutex
+}
+
+func NewCertificateReloader(certFile, keyFile string) (*CertificateReloader, error) {
+	c := &CertificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	modTime, err := c.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cert = &cert
+	c.modTime = modTime
+	c.checked = time.Now()
+
+	return c, nil
+}
+
+func (c *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checked) < certCheckInterval {
+		return c.cert, nil
+	}
+	c.checked = time.Now()
+
+	modTime, err := c.latestModTime()
+	if err != nil || !modTime.After(c.modTime) {
+		return c.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		slog.Warn("Failed to reload TLS certificate, keeping the current one", "error", err)
+		return c.cert, nil
+	}
+
+	c.cert = &cert
+	c.modTime = modTime
+	logging.Success("Reloaded TLS certificate", "file", filepath.Base(c.certFile))
+
+	return c.cert, nil
+}
+
+func (c *CertificateReloader) latestModTime() (time.Time, error) {
+	certInfo, err := os.Stat(c.certFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	keyInfo, err := os.Stat(c.keyFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if keyInfo.ModTime().After(certInfo.ModTime()) {
+		return keyInfo.ModTime(), nil
+	}
+	return certInfo.ModTime(), nil
+}
+
+func EnsureSelfSignedCertificate(certFile, keyFile string, hosts []string) (bool, error) {
+	_, certErr := os.Stat(certFile)
+	_, keyErr := os.Stat(keyFile)
+	if certErr == nil && keyErr == nil {
+		return false, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return false, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return false, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"ZIMServer"}, CommonName: hosts[0]},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return false, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return false, err
+	}
+
+	if err := writePEM(certFile, "CERTIFICATE", der, 0644); err != nil {
+		return false, err
+	}
+	if err := writePEM(keyFile, "EC PRIVATE KEY", keyDER, 0600); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if err := pem.Encode(file, &pem.Block{Type: blockType, Bytes: der}); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return file.Close()
+}
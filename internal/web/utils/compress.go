@@ -0,0 +1,347 @@
+package utils
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingGzip = "gzip"
+	encodingZstd = "zstd"
+
+	compressionMinSize = 1024
+)
+
+var compressibleTypes = []string{
+	"text/",
+	"application/json",
+	"application/javascript",
+	"application/x-javascript",
+	"application/ecmascript",
+	"application/xml",
+	"application/xhtml+xml",
+	"application/rss+xml",
+	"application/atom+xml",
+	"application/opensearchdescription+xml",
+	"application/wasm",
+	"image/svg+xml",
+	"image/x-icon",
+	"font/ttf",
+	"font/otf",
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	},
+}
+
+var zstdWriterPool = sync.Pool{
+	New: func() interface{} {
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return w
+	},
+}
+
+type CompressionCache struct {
+	maxBytes int64
+	size     int64
+	entries  map[string]*list.Element
+	lru      *list.List
+	hits     uint64
+	misses   uint64
+	mu       sync.Mutex
+}
+
+type compressionCacheEntry struct {
+	key  string
+	body []byte
+}
+
+func NewCompressionCache(maxBytes int64) *CompressionCache {
+	return &CompressionCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (c *CompressionCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.entries[key]
+	if !exists {
+		c.misses++
+		return nil, false
+	}
+
+	c.hits++
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*compressionCacheEntry).body, true
+}
+
+func (c *CompressionCache) Put(key string, body []byte) {
+	size := int64(len(body))
+	if size > c.maxBytes/4 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; exists {
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&compressionCacheEntry{key: key, body: body})
+	c.size += size
+
+	for c.size > c.maxBytes {
+		oldest := c.lru.Back()
+		entry := oldest.Value.(*compressionCacheEntry)
+		c.lru.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= int64(len(entry.body))
+	}
+}
+
+func (c *CompressionCache) Stats() (hits, misses uint64, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses, c.size
+}
+
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	cache       *CompressionCache
+	status      int
+	buf         []byte
+	decided     bool
+	passthrough bool
+	discard     bool
+	encoder     io.WriteCloser
+	cacheKey    string
+	cacheBuf    *bytes.Buffer
+	err         error
+}
+
+func CompressionMiddleware(next http.Handler, cache *CompressionCache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if inm := r.Header.Get("If-None-Match"); inm != "" {
+			r.Header.Set("If-None-Match", stripEncodingSuffix(inm))
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: w,
+			encoding:       encoding,
+			cache:          cache,
+			status:         http.StatusOK,
+		}
+		defer cw.Close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != encodingGzip && name != encodingZstd {
+			continue
+		}
+
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		if q > bestQ || (q == bestQ && name == encodingZstd) {
+			best, bestQ = name, q
+		}
+	}
+
+	return best
+}
+
+func stripEncodingSuffix(etags string) string {
+	etags = strings.ReplaceAll(etags, "-"+encodingGzip+"\"", "\"")
+	return strings.ReplaceAll(etags, "-"+encodingZstd+"\"", "\"")
+}
+
+func isCompressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.decided {
+		return
+	}
+	cw.status = code
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) >= compressionMinSize {
+			cw.decide(true)
+		}
+		return len(p), nil
+	}
+
+	switch {
+	case cw.discard:
+		return len(p), nil
+	case cw.passthrough:
+		return cw.ResponseWriter.Write(p)
+	default:
+		n, err := cw.encoder.Write(p)
+		if err != nil && cw.err == nil {
+			cw.err = err
+		}
+		return n, err
+	}
+}
+
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.decide(len(cw.buf) >= compressionMinSize)
+	}
+
+	if cw.encoder != nil {
+		if flusher, ok := cw.encoder.(interface{ Flush() error }); ok {
+			if err := flusher.Flush(); err != nil && cw.err == nil {
+				cw.err = err
+			}
+		}
+	}
+
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (cw *compressWriter) Close() {
+	if !cw.decided {
+		cw.decide(false)
+	}
+
+	if cw.encoder == nil {
+		return
+	}
+
+	if err := cw.encoder.Close(); err != nil && cw.err == nil {
+		cw.err = err
+	}
+
+	switch encoder := cw.encoder.(type) {
+	case *gzip.Writer:
+		encoder.Reset(nil)
+		gzipWriterPool.Put(encoder)
+	case *zstd.Encoder:
+		encoder.Reset(nil)
+		zstdWriterPool.Put(encoder)
+	}
+
+	if cw.cacheBuf != nil && cw.err == nil && cw.status == http.StatusOK {
+		cw.cache.Put(cw.cacheKey, cw.cacheBuf.Bytes())
+	}
+}
+
+func (cw *compressWriter) decide(large bool) {
+	cw.decided = true
+	header := cw.Header()
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" && len(cw.buf) > 0 {
+		contentType = http.DetectContentType(cw.buf)
+		header.Set("Content-Type", contentType)
+	}
+
+	compressible := isCompressible(contentType)
+	if compressible {
+		header.Add("Vary", "Accept-Encoding")
+	}
+
+	if !large || !compressible || cw.status != http.StatusOK || header.Get("Content-Encoding") != "" {
+		cw.passthrough = true
+		cw.ResponseWriter.WriteHeader(cw.status)
+		if len(cw.buf) > 0 {
+			cw.ResponseWriter.Write(cw.buf)
+		}
+		cw.buf = nil
+		return
+	}
+
+	header.Del("Content-Length")
+	header.Del("Accept-Ranges")
+	header.Set("Content-Encoding", cw.encoding)
+
+	etag := header.Get("ETag")
+	if strings.HasSuffix(etag, "\"") {
+		header.Set("ETag", etag[:len(etag)-1]+"-"+cw.encoding+"\"")
+	}
+
+	if cw.cache != nil && etag != "" {
+		cw.cacheKey = etag + ":" + cw.encoding
+		if body, found := cw.cache.Get(cw.cacheKey); found {
+			cw.discard = true
+			header.Set("Content-Length", strconv.Itoa(len(body)))
+			cw.ResponseWriter.WriteHeader(cw.status)
+			cw.ResponseWriter.Write(body)
+			cw.buf = nil
+			return
+		}
+		cw.cacheBuf = &bytes.Buffer{}
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	var out io.Writer = cw.ResponseWriter
+	if cw.cacheBuf != nil {
+		out = io.MultiWriter(cw.ResponseWriter, cw.cacheBuf)
+	}
+
+	switch cw.encoding {
+	case encodingZstd:
+		encoder := zstdWriterPool.Get().(*zstd.Encoder)
+		encoder.Reset(out)
+		cw.encoder = encoder
+	default:
+		encoder := gzipWriterPool.Get().(*gzip.Writer)
+		encoder.Reset(out)
+		cw.encoder = encoder
+	}
+
+	if _, err := cw.encoder.Write(cw.buf); err != nil {
+		cw.err = err
+	}
+	cw.buf = nil
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"bytes"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type Heading struct {
+	Level int
+	ID    string
+	Title string
+	Index int
+}
+
+type Link struct {
+	Tag  string
+	Attr string
+	URL  string
+}
+
+var linkAttributes = map[string]string{
+	"a":      "href",
+	"area":   "href",
+	"link":   "href",
+	"img":    "src",
+	"script": "src",
+	"source": "src",
+	"audio":  "src",
+	"video":  "src",
+	"track":  "src",
+	"iframe": "src",
+	"embed":  "src",
+}
+
+func ExtractLinks(content []byte) []Link {
+	links := make([]Link, 0)
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			return links
+		}
+
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+
+		name, hasAttr := tokenizer.TagName()
+		attrName, ok := linkAttributes[string(name)]
+		if !ok || !hasAttr {
+			continue
+		}
+
+		for {
+			key, val, more := tokenizer.TagAttr()
+			if string(key) == attrName && len(val) > 0 {
+				links = append(links, Link{
+					Tag:  string(name),
+					Attr: attrName,
+					URL:  string(val),
+				})
+			}
+			if !more {
+				break
+			}
+		}
+	}
+}
+
+func IsExternalURL(ref string) bool {
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return false
+	}
+	return u.Scheme != "" || u.Host != ""
+}
+
+func ResolveEntryPath(basePath, ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return "", false
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "" || u.Host != "" || strings.HasPrefix(u.Path, "/") {
+		return "", false
+	}
+
+	if u.Path == "" {
+		return "", false
+	}
+
+	resolved := path.Join(path.Dir(basePath), u.Path)
+	if resolved == "." || strings.HasPrefix(resolved, "../") || resolved == ".." {
+		return "", false
+	}
+
+	return resolved, true
+}
+
+func ExtractHeadings(content []byte) []Heading {
+	headings := make([]Heading, 0)
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+
+	var current *Heading
+	var title strings.Builder
+	skipDepth := 0
+	headingCount := 0
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			return headings
+		}
+
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			tag := string(name)
+
+			if tag == "script" || tag == "style" {
+				if tokenType == html.StartTagToken {
+					skipDepth++
+				}
+				continue
+			}
+
+			id := ""
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = tokenizer.TagAttr()
+				if string(key) == "id" {
+					id = string(val)
+				}
+			}
+
+			if level := headingLevel(tag); level > 0 {
+				current = &Heading{
+					Level: level,
+					ID:    id,
+					Index: headingCount,
+				}
+				headingCount++
+				title.Reset()
+				continue
+			}
+
+			if current != nil && current.ID == "" && id != "" {
+				current.ID = id
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+
+			if (tag == "script" || tag == "style") && skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+
+			if current != nil && headingLevel(tag) == current.Level {
+				current.Title = strings.Join(strings.Fields(title.String()), " ")
+				if current.Title != "" {
+					headings = append(headings, *current)
+				}
+				current = nil
+			}
+
+		case html.TextToken:
+			if current != nil && skipDepth == 0 {
+				title.Write(tokenizer.Text())
+			}
+		}
+	}
+}
+
+func headingLevel(tag string) int {
+	if len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6' {
+		return int(tag[1] - '0')
+	}
+	return 0
+}
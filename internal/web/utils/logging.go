@@ -1,26 +1,54 @@
 package utils
 
 import (
-	"log"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/gaetanlhf/ZIMServer/internal/logging"
+	"github.com/gaetanlhf/ZIMServer/internal/metrics"
 )
 
-const (
-	colorReset  = "\033[0m"
-	colorRed    = "\033[31m"
-	colorGreen  = "\033[32m"
-	colorYellow = "\033[33m"
-	colorGray   = "\033[90m"
+type AccessLog struct {
+	Writer io.Writer
+	JSON   bool
+}
 
-	symbolSuccess = "✓"
-	symbolWarning = "⚠"
-	symbolError   = "✗"
-)
+type accessEntry struct {
+	Time      string  `json:"time"`
+	Remote    string  `json:"remote"`
+	User      string  `json:"user,omitempty"`
+	Method    string  `json:"method"`
+	URI       string  `json:"uri"`
+	Proto     string  `json:"proto"`
+	Host      string  `json:"host"`
+	Status    int     `json:"status"`
+	Bytes     int64   `json:"bytes"`
+	Duration  float64 `json:"duration_ms"`
+	Archive   string  `json:"archive,omitempty"`
+	Referer   string  `json:"referer,omitempty"`
+	UserAgent string  `json:"user_agent,omitempty"`
+}
+
+type requestLog struct {
+	user     string
+	clientIP net.IP
+	path     string
+	archive  string
+}
+
+type requestLogKey struct{}
 
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	bytes      int64
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
@@ -28,39 +56,180 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-func LoggingMiddleware(next http.Handler) http.Handler {
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(p)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+var routes = []string{"assets", "viewer", "read", "content", "gallery", "api", "catalog", "download", "raw", "search", "suggest", "random", "catch", "login", "logout", "healthz", "readyz"}
+
+func LoggingMiddleware(next http.Handler, requests bool, access *AccessLog, metricsPath string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		info := &requestLog{path: r.URL.Path}
 		rw := &responseWriter{
 			ResponseWriter: w,
 			statusCode:     200,
 		}
 
-		next.ServeHTTP(rw, r)
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), requestLogKey{}, info)))
 
 		duration := time.Since(start)
 
-		var logSymbol string
-		var statusColor string
-
-		if rw.statusCode >= 500 {
-			logSymbol = symbolError
-			statusColor = colorRed
-		} else if rw.statusCode >= 400 {
-			logSymbol = symbolWarning
-			statusColor = colorYellow
-		} else {
-			logSymbol = symbolSuccess
-			statusColor = colorGreen
+		if requests {
+			level := logging.LevelSuccess
+			if rw.statusCode >= 500 {
+				level = slog.LevelError
+			} else if rw.statusCode >= 400 {
+				level = slog.LevelWarn
+			}
+
+			slog.Log(r.Context(), level, "request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", rw.statusCode,
+				"duration", duration.Round(time.Millisecond),
+			)
 		}
 
-		log.Printf("%s%s%s %s%s%s %s %s%d%s %v",
-			statusColor, logSymbol, colorReset,
-			colorGray, r.Method, colorReset,
-			r.URL.Path,
-			statusColor, rw.statusCode, colorReset,
-			duration.Round(time.Millisecond),
-		)
+		if access != nil {
+			access.write(r, info, rw, start, duration)
+		}
+
+		if metricsPath != "" {
+			metrics.ObserveRequest(requestRoute(info.path, metricsPath), info.archive, rw.statusCode, rw.bytes, duration)
+		}
 	})
 }
+
+func requestRoute(path, metricsPath string) string {
+	if path == "/" {
+		return "home"
+	}
+
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	for _, route := range routes {
+		if segment == route {
+			return route
+		}
+	}
+	if path == metricsPath {
+		return "metrics"
+	}
+	return "other"
+}
+
+func SetRequestUser(ctx context.Context, user string) {
+	if info, ok := ctx.Value(requestLogKey{}).(*requestLog); ok {
+		info.user = user
+	}
+}
+
+func SetRequestArchive(ctx context.Context, name string) {
+	if info, ok := ctx.Value(requestLogKey{}).(*requestLog); ok {
+		info.archive = name
+	}
+}
+
+func setRequestLog(ctx context.Context, clientIP net.IP, path string) {
+	if info, ok := ctx.Value(requestLogKey{}).(*requestLog); ok {
+		info.clientIP = clientIP
+		info.path = path
+	}
+}
+
+func (a *AccessLog) write(r *http.Request, info *requestLog, rw *responseWriter, start time.Time, duration time.Duration) {
+	remote := remoteHost(r.RemoteAddr)
+	if info.clientIP != nil {
+		remote = info.clientIP.String()
+	}
+
+	var err error
+	if a.JSON {
+		err = json.NewEncoder(a.Writer).Encode(accessEntry{
+			Time:      start.Format(time.RFC3339Nano),
+			Remote:    remote,
+			User:      info.user,
+			Method:    r.Method,
+			URI:       r.RequestURI,
+			Proto:     r.Proto,
+			Host:      r.Host,
+			Status:    rw.statusCode,
+			Bytes:     rw.bytes,
+			Duration:  float64(duration.Microseconds()) / 1000,
+			Archive:   RequestArchive(info.path, r),
+			Referer:   r.Referer(),
+			UserAgent: r.UserAgent(),
+		})
+	} else {
+		size := "-"
+		if rw.bytes > 0 {
+			size = fmt.Sprint(rw.bytes)
+		}
+
+		_, err = fmt.Fprintf(a.Writer, "%s - %s [%s] %q %d %s %q %q\n",
+			remote,
+			orDash(info.user),
+			start.Format("02/Jan/2006:15:04:05 -0700"),
+			r.Method+" "+r.RequestURI+" "+r.Proto,
+			rw.statusCode,
+			size,
+			orDash(r.Referer()),
+			orDash(r.UserAgent()),
+		)
+	}
+
+	if err != nil {
+		slog.Warn("Failed to write access log", "error", err)
+	}
+}
+
+func RequestArchive(path string, r *http.Request) string {
+	for _, prefix := range []string{"/content/", "/viewer/", "/read/", "/gallery/", "/raw/", "/api/", "/download/"} {
+		rest, found := strings.CutPrefix(path, prefix)
+		if !found {
+			continue
+		}
+
+		name, _, _ := strings.Cut(rest, "/")
+		switch prefix {
+		case "/api/":
+			if name == "archives" {
+				return ""
+			}
+		case "/download/":
+			name = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(name, ".sha256"), ".md5"), ".zim")
+		}
+		return name
+	}
+
+	if content := r.URL.Query().Get("content"); content != "" {
+		return content
+	}
+	return r.URL.Query().Get("books.name")
+}
+
+func remoteHost(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	if remoteAddr == "" || remoteAddr == "@" {
+		return "-"
+	}
+	return remoteAddr
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
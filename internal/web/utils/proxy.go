@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type clientIPKey struct{}
+type urlRootKey struct{}
+type secureKey struct{}
+
+func NormalizeURLRoot(root string) string {
+	root = strings.Trim(strings.TrimSpace(root), "/")
+	if root == "" {
+		return ""
+	}
+	return "/" + root
+}
+
+func ProxyMiddleware(next http.Handler, root string, trustedProxies []*net.IPNet, trustUnix bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := remoteIP(r.RemoteAddr)
+		secure := r.TLS != nil
+		host := r.Host
+		prefix := ""
+
+		if trustUnix && isUnixSocket(r) || ip != nil && isTrusted(ip, trustedProxies) {
+			if forwarded := forwardedClientIP(r.Header.Get("X-Forwarded-For"), trustedProxies); forwarded != nil {
+				ip = forwarded
+			}
+			if proto := firstValue(r.Header.Get("X-Forwarded-Proto")); proto != "" {
+				secure = strings.EqualFold(proto, "https")
+			}
+			if forwardedHost := firstValue(r.Header.Get("X-Forwarded-Host")); forwardedHost != "" {
+				host = forwardedHost
+			}
+			prefix = forwardedPrefix(r.Header.Get("X-Forwarded-Prefix"))
+		}
+
+		path := r.URL.Path
+		if root != "" {
+			switch {
+			case path == root:
+				http.Redirect(w, r, prefix+root+"/", http.StatusMovedPermanently)
+				return
+			case !strings.HasPrefix(path, root+"/"):
+				http.NotFound(w, r)
+				return
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), urlRootKey{}, prefix+root)
+		ctx = context.WithValue(ctx, secureKey{}, secure)
+		if ip != nil {
+			ctx = context.WithValue(ctx, clientIPKey{}, ip)
+		}
+
+		setRequestLog(ctx, ip, strings.TrimPrefix(path, root))
+
+		r = r.WithContext(ctx)
+		r.Host = host
+		if root != "" {
+			u := *r.URL
+			u.Path = strings.TrimPrefix(path, root)
+			u.RawPath = strings.TrimPrefix(u.RawPath, root)
+			r.URL = &u
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func ClientIPFromContext(ctx context.Context) (net.IP, bool) {
+	ip, ok := ctx.Value(clientIPKey{}).(net.IP)
+	return ip, ok
+}
+
+func URLRoot(ctx context.Context) string {
+	root, _ := ctx.Value(urlRootKey{}).(string)
+	return root
+}
+
+func RootURL(r *http.Request, path string) string {
+	return URLRoot(r.Context()) + path
+}
+
+func IsSecure(r *http.Request) bool {
+	if secure, ok := r.Context().Value(secureKey{}).(bool); ok {
+		return secure
+	}
+	return r.TLS != nil
+}
+
+func ParseNetwork(entry string) (*net.IPNet, error) {
+	if strings.Contains(entry, "/") {
+		_, network, err := net.ParseCIDR(entry)
+		return network, err
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", entry)
+	}
+
+	bits := 128
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 32
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+func isUnixSocket(r *http.Request) bool {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return ok && addr.Network() == "unix"
+}
+
+func remoteIP(remoteAddr string) net.IP {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+func isTrusted(ip net.IP, trustedProxies []*net.IPNet) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func forwardedClientIP(header string, trustedProxies []*net.IPNet) net.IP {
+	if header == "" {
+		return nil
+	}
+
+	hops := strings.Split(header, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			return nil
+		}
+		if i == 0 || !isTrusted(ip, trustedProxies) {
+			return ip
+		}
+	}
+
+	return nil
+}
+
+func firstValue(header string) string {
+	value, _, _ := strings.Cut(header, ",")
+	return strings.TrimSpace(value)
+}
+
+func forwardedPrefix(header string) string {
+	prefix := NormalizeURLRoot(firstValue(header))
+	if strings.ContainsAny(prefix, "\"'<>\\ ") || strings.Contains(prefix, "//") {
+		return ""
+	}
+	return prefix
+}
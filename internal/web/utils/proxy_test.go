@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxyMiddlewareTrust(t *testing.T) {
+	trusted := []*net.IPNet{
+		mustParseNetwork(t, "10.0.0.0/8"),
+		mustParseNetwork(t, "::1"),
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		unix       bool
+		trustUnix  bool
+		forwarded  string
+		proto      string
+		wantIP     string
+		wantSecure bool
+	}{
+		{"direct client", "203.0.113.5:1234", false, false, "", "", "203.0.113.5", false},
+		{"untrusted client spoofing", "203.0.113.5:1234", false, false, "198.51.100.1", "https", "203.0.113.5", false},
+		{"trusted proxy", "10.1.2.3:1234", false, false, "198.51.100.1", "https", "198.51.100.1", true},
+		{"trusted IPv6 proxy", "[::1]:1234", false, false, "198.51.100.1", "", "198.51.100.1", false},
+		{"trusted proxy chain", "10.1.2.3:1234", false, false, "198.51.100.1, 10.9.9.9", "", "198.51.100.1", false},
+		{"spoofed hop before untrusted", "10.1.2.3:1234", false, false, "192.0.2.7, 198.51.100.1", "", "198.51.100.1", false},
+		{"invalid forwarded address", "10.1.2.3:1234", false, false, "not-an-ip", "", "10.1.2.3", false},
+		{"unix socket not trusted", "@", true, false, "198.51.100.1", "https", "", false},
+		{"unix socket trusted", "@", true, true, "198.51.100.1", "https", "198.51.100.1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotIP net.IP
+			var gotSecure bool
+			handler := ProxyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotIP, _ = ClientIPFromContext(r.Context())
+				gotSecure = IsSecure(r)
+			}), "", trusted, tt.trustUnix)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.unix {
+				addr := &net.UnixAddr{Name: "/run/zimserver.sock", Net: "unix"}
+				r = r.WithContext(context.WithValue(r.Context(), http.LocalAddrContextKey, addr))
+			}
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.proto != "" {
+				r.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if tt.wantIP == "" {
+				if gotIP != nil {
+					t.Errorf("client IP = %v, want none", gotIP)
+				}
+			} else if !gotIP.Equal(net.ParseIP(tt.wantIP)) {
+				t.Errorf("client IP = %v, want %s", gotIP, tt.wantIP)
+			}
+			if gotSecure != tt.wantSecure {
+				t.Errorf("secure = %v, want %v", gotSecure, tt.wantSecure)
+			}
+		})
+	}
+}
+
+func TestForwardedPrefix(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"/library", "/library"},
+		{"library/", "/library"},
+		{"/a, /b", "/a"},
+		{"//evil.example", "/evil.example"},
+		{"/a//b", ""},
+		{"/a\"onload=x", ""},
+		{"/<script>", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := forwardedPrefix(tt.header); got != tt.want {
+				t.Errorf("forwardedPrefix(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func mustParseNetwork(t *testing.T, entry string) *net.IPNet {
+	t.Helper()
+	network, err := ParseNetwork(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return network
+}
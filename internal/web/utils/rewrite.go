@@ -0,0 +1,344 @@
+package utils
+
+import (
+	"bytes"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+const RewrittenAttr = "data-zimserver-rewritten"
+
+var navigationTags = map[string]bool{
+	"a":    true,
+	"area": true,
+}
+
+type Article struct {
+	Title string
+	Head  []byte
+	Body  []byte
+}
+
+var urlAttributes = map[string]bool{
+	"href":       true,
+	"src":        true,
+	"action":     true,
+	"formaction": true,
+	"xlink:href": true,
+	"data":       true,
+	"poster":     true,
+	"background": true,
+}
+
+var headTags = map[string]bool{
+	"html":     true,
+	"head":     true,
+	"title":    true,
+	"meta":     true,
+	"link":     true,
+	"style":    true,
+	"script":   true,
+	"base":     true,
+	"noscript": true,
+}
+
+func RewriteHTML(content []byte, root, archiveName, entryPath string) []byte {
+	contentPrefix := root + "/content/" + archiveName + "/"
+	navHookScript := `<script src="` + root + `/assets/js/navhook.js" defer></script>`
+
+	var out bytes.Buffer
+	out.Grow(len(content) + len(navHookScript) + 256)
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+	hookInjected := false
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+
+		raw := tokenizer.Raw()
+
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			changed := false
+
+			switch token.Data {
+			case "html":
+				token.Attr = append(token.Attr, html.Attribute{Key: RewrittenAttr})
+				changed = true
+			case "body":
+				if !hookInjected {
+					out.WriteString(navHookScript)
+					hookInjected = true
+				}
+			}
+
+			if attrName, ok := linkAttributes[token.Data]; ok {
+				for i, attr := range token.Attr {
+					if attr.Key != attrName {
+						continue
+					}
+					if rewritten, ok := rewriteURL(attr.Val, root, entryPath, contentPrefix, contentPrefix, navigationTags[token.Data]); ok {
+						token.Attr[i].Val = rewritten
+						changed = true
+					}
+				}
+			}
+
+			if changed {
+				out.WriteString(token.String())
+			} else {
+				out.Write(raw)
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if string(name) == "head" && !hookInjected {
+				out.WriteString(navHookScript)
+				hookInjected = true
+			}
+			out.Write(raw)
+
+		default:
+			out.Write(raw)
+		}
+	}
+
+	if !hookInjected {
+		out.WriteString(navHookScript)
+	}
+
+	return out.Bytes()
+}
+
+func RewriteArticle(content []byte, root, archiveName, entryPath string) Article {
+	contentPrefix := root + "/content/" + archiveName + "/"
+	pagePrefix := root + "/read/" + archiveName + "/"
+
+	var article Article
+	var head, body bytes.Buffer
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+	inBody := false
+	inTitle := false
+	skipDepth := 0
+	keepStyle := false
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+
+		raw := tokenizer.Raw()
+
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+
+			if token.Data == "script" {
+				if tokenType == html.StartTagToken {
+					skipDepth++
+				}
+				continue
+			}
+
+			if !inBody && (token.Data == "body" || !headTags[token.Data]) {
+				inBody = true
+			}
+
+			if !inBody {
+				switch token.Data {
+				case "title":
+					inTitle = tokenType == html.StartTagToken
+				case "style":
+					keepStyle = tokenType == html.StartTagToken
+					head.Write(raw)
+				case "link":
+					if isStylesheet(token) {
+						rewriteAttrs(&token, root, entryPath, contentPrefix, pagePrefix)
+						head.WriteString(token.String())
+					}
+				}
+				continue
+			}
+
+			if token.Data == "body" || token.Data == "html" {
+				continue
+			}
+
+			stripped := stripScripting(&token)
+			if rewriteAttrs(&token, root, entryPath, contentPrefix, pagePrefix) || stripped {
+				body.WriteString(token.String())
+			} else {
+				body.Write(raw)
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+
+			switch tag {
+			case "script":
+				if skipDepth > 0 {
+					skipDepth--
+				}
+			case "title":
+				inTitle = false
+			case "head":
+				inBody = true
+			case "style":
+				if keepStyle {
+					head.Write(raw)
+					keepStyle = false
+					continue
+				}
+				if inBody {
+					body.Write(raw)
+				}
+			case "body", "html":
+			default:
+				if inBody {
+					body.Write(raw)
+				}
+			}
+
+		case html.TextToken:
+			switch {
+			case skipDepth > 0:
+			case inTitle:
+				article.Title = strings.Join(strings.Fields(html.UnescapeString(string(raw))), " ")
+			case keepStyle:
+				head.Write(raw)
+			case inBody:
+				body.Write(raw)
+			}
+
+		default:
+			if inBody && skipDepth == 0 {
+				body.Write(raw)
+			}
+		}
+	}
+
+	article.Head = head.Bytes()
+	article.Body = body.Bytes()
+
+	return article
+}
+
+func stripScripting(token *html.Token) bool {
+	attrs := token.Attr[:0]
+	for _, attr := range token.Attr {
+		if strings.HasPrefix(attr.Key, "on") || attr.Key == "srcdoc" || (urlAttributes[attr.Key] && isScriptURL(attr.Val)) {
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+
+	stripped := len(attrs) != len(token.Attr)
+	token.Attr = attrs
+	return stripped
+}
+
+func isScriptURL(value string) bool {
+	scheme := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, value)
+	return strings.HasPrefix(scheme, "javascript:") || strings.HasPrefix(scheme, "vbscript:")
+}
+
+func isStylesheet(token html.Token) bool {
+	for _, attr := range token.Attr {
+		if attr.Key == "rel" && strings.Contains(strings.ToLower(attr.Val), "stylesheet") {
+			return true
+		}
+	}
+	return false
+}
+
+func rewriteAttrs(token *html.Token, root, entryPath, contentPrefix, pagePrefix string) bool {
+	attrName, ok := linkAttributes[token.Data]
+	if !ok {
+		return false
+	}
+
+	changed := false
+	for i, attr := range token.Attr {
+		if attr.Key != attrName {
+			continue
+		}
+		if rewritten, ok := rewriteURL(attr.Val, root, entryPath, contentPrefix, pagePrefix, navigationTags[token.Data]); ok {
+			token.Attr[i].Val = rewritten
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+func rewriteURL(ref, root, entryPath, contentPrefix, pagePrefix string, navigation bool) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return "", false
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+
+	if u.Scheme != "" || u.Host != "" {
+		switch strings.ToLower(u.Scheme) {
+		case "http", "https", "":
+			if !navigation {
+				return "", false
+			}
+			if u.Scheme == "" {
+				u.Scheme = "https"
+			}
+			return root + "/catch?url=" + url.QueryEscape(u.String()), true
+		}
+		return "", false
+	}
+
+	prefix := contentPrefix
+	if navigation {
+		prefix = pagePrefix
+	}
+
+	var targetPath string
+	if strings.HasPrefix(u.Path, "/") {
+		if strings.HasPrefix(u.Path, contentPrefix) {
+			if prefix == contentPrefix {
+				return "", false
+			}
+			u.Path = "/" + strings.TrimPrefix(u.Path, contentPrefix)
+		}
+		targetPath = strings.TrimPrefix(path.Clean(u.Path), "/")
+	} else {
+		resolved, ok := ResolveEntryPath(entryPath, ref)
+		if !ok {
+			return "", false
+		}
+		targetPath = resolved
+	}
+
+	target := &url.URL{
+		Path:     prefix + targetPath,
+		RawQuery: u.RawQuery,
+		Fragment: u.Fragment,
+	}
+
+	return target.String(), true
+}
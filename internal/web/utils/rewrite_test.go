@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewriteHTML(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		entryPath string
+		want      []string
+		notWant   []string
+	}{
+		{
+			name:      "relative links",
+			input:     `<html><head></head><body><a href="Other.html#s">x</a><img src="../I/pic.png"></body></html>`,
+			entryPath: "A/Page.html",
+			want: []string{
+				`href="/lib/content/wiki/A/Other.html#s"`,
+				`src="/lib/content/wiki/I/pic.png"`,
+				RewrittenAttr,
+			},
+			notWant: []string{`href="Other.html`, `src="../I/pic.png"`},
+		},
+		{
+			name:      "external links go through catch",
+			input:     `<body><a href="https://example.org/a?b=c">x</a><img src="https://example.org/i.png"></body>`,
+			entryPath: "Page",
+			want: []string{
+				`href="/lib/catch?url=https%3A%2F%2Fexample.org%2Fa%3Fb%3Dc"`,
+				`src="https://example.org/i.png"`,
+			},
+		},
+		{
+			name:      "anchors and other schemes are kept",
+			input:     `<body><a href="#top">x</a><a href="mailto:a@example.org">y</a></body>`,
+			entryPath: "Page",
+			want:      []string{`href="#top"`, `href="mailto:a@example.org"`},
+		},
+		{
+			name:      "hook injected at the end of head",
+			input:     `<html><head><title>t</title></head><body><p>x</p></body></html>`,
+			entryPath: "Page",
+			want:      []string{`<script src="/lib/assets/js/navhook.js" defer></script></head>`},
+		},
+		{
+			name:      "hook appended without body",
+			input:     `<p>fragment</p>`,
+			entryPath: "Page",
+			want:      []string{`<p>fragment</p><script src="/lib/assets/js/navhook.js" defer></script>`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(RewriteHTML([]byte(tt.input), "/lib", "wiki", tt.entryPath))
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("output missing %q\n%s", want, got)
+				}
+			}
+			if n := strings.Count(got, "navhook.js"); n != 1 {
+				t.Errorf("navhook injected %d times\n%s", n, got)
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("output contains %q\n%s", notWant, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRewriteArticle(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantHead []string
+		wantBody []string
+		notWant  []string
+	}{
+		{
+			name:     "title, styles and links",
+			input:    `<html><head><title> A  Page </title><link rel="stylesheet" href="s.css"><link rel="icon" href="f.ico"><style>p{}</style></head><body><a href="B">b</a><img src="i.png"></body></html>`,
+			wantHead: []string{`href="/lib/content/wiki/A/s.css"`, `<style>p{}</style>`},
+			wantBody: []string{`href="/lib/read/wiki/A/B"`, `src="/lib/content/wiki/A/i.png"`},
+			notWant:  []string{"f.ico", "<title>"},
+		},
+		{
+			name:     "scripts removed",
+			input:    `<head><script src="x.js"></script></head><body><p>a</p><script>alert(1)</script><svg><script>alert(2)</script></svg><p>b</p></body>`,
+			wantBody: []string{`<p>a</p>`, `<p>b</p>`},
+			notWant:  []string{"<script", "alert", "x.js"},
+		},
+		{
+			name:     "event handlers removed",
+			input:    `<body><img src="i.png" onerror="alert(1)"><div ONCLICK="alert(2)" class="c">x</div><svg onload="alert(3)"></svg></body>`,
+			wantBody: []string{`class="c"`, `src="/lib/content/wiki/A/i.png"`},
+			notWant:  []string{"alert", "onerror", "onclick", "onload"},
+		},
+		{
+			name:     "script URLs removed",
+			input:    `<body><a href="javascript:alert(1)">a</a><a href=" JaVa&#x09;ScRiPt:alert(2)">b</a><form action="vbscript:x"></form><iframe srcdoc="<script>alert(3)</script>"></iframe></body>`,
+			wantBody: []string{`<a>a</a>`, `<a>b</a>`, `<form>`},
+			notWant:  []string{"alert", "script:", "srcdoc"},
+		},
+		{
+			name:     "other URLs kept",
+			input:    `<body><a href="https://example.org/">e</a><a href="#s">s</a><img src="data:image/png;base64,AA=="></body>`,
+			wantBody: []string{`href="/lib/catch?url=https%3A%2F%2Fexample.org%2F"`, `href="#s"`, `src="data:image/png;base64,AA=="`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := RewriteArticle([]byte(tt.input), "/lib", "wiki", "A/Page")
+			head, body := string(article.Head), string(article.Body)
+
+			for _, want := range tt.wantHead {
+				if !strings.Contains(head, want) {
+					t.Errorf("head missing %q\n%s", want, head)
+				}
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(body, want) {
+					t.Errorf("body missing %q\n%s", want, body)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(strings.ToLower(head+body), strings.ToLower(notWant)) {
+					t.Errorf("output contains %q\nhead: %s\nbody: %s", notWant, head, body)
+				}
+			}
+		})
+	}
+}
+
+func TestRewriteArticleTitle(t *testing.T) {
+	article := RewriteArticle([]byte(`<html><head><title> A &amp;  B </title></head><body></body></html>`), "", "wiki", "Page")
+	if article.Title != "A & B" {
+		t.Errorf("Title = %q, want %q", article.Title, "A & B")
+	}
+}
+
+func TestIsScriptURL(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"javascript:alert(1)", true},
+		{"JAVASCRIPT:alert(1)", true},
+		{" \tjava\nscript:alert(1)", true},
+		{"vbscript:msgbox", true},
+		{"https://example.org/javascript:", false},
+		{"page.html", false},
+		{"data:text/html,<p>", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := isScriptURL(tt.value); got != tt.want {
+				t.Errorf("isScriptURL(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -50,6 +50,13 @@ func (m *Manager) HasTitleV1() bool {
 	return m.hasV1
 }
 
+func (m *Manager) ArticleCount() int {
+	if m.hasV1 {
+		return m.titleV1.Size()
+	}
+	return 0
+}
+
 func (m *Manager) Search(query string, maxResults int) ([]SearchResult, error) {
 	if m.hasV1 {
 		return m.titleV1.Search(query, maxResults)
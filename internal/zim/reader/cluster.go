@@ -5,6 +5,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"sync/atomic"
+	"time"
 
 	"github.com/klauspost/compress/zstd"
 	"github.com/ulikunitz/xz"
@@ -56,15 +58,29 @@ func (c *Cluster) ReadBlob(blobIndex uint32) ([]byte, error) {
 	return data[startOffset:endOffset], nil
 }
 
-func (c *Cluster) readUncompressedData() ([]byte, error) {
+func (c *Cluster) readInfo() (byte, error) {
 	clusterInfo := make([]byte, 1)
 	if _, err := c.reader.ReadAt(clusterInfo, int64(c.offset)); err != nil {
-		return nil, fmt.Errorf("failed to read cluster info: %w", err)
+		return 0, fmt.Errorf("failed to read cluster info: %w", err)
 	}
 
 	c.Compression = CompressionType(clusterInfo[0] & 0x0F)
 	c.Extended = (clusterInfo[0] & 0x10) != 0
 
+	return clusterInfo[0], nil
+}
+
+func (c *Cluster) Size() uint64 {
+	return c.size
+}
+
+func (c *Cluster) readUncompressedData() ([]byte, error) {
+	info, err := c.readInfo()
+	if err != nil {
+		return nil, err
+	}
+	clusterInfo := []byte{info}
+
 	compressedData := make([]byte, c.size-1)
 	if _, err := c.reader.ReadAt(compressedData, int64(c.offset+1)); err != nil {
 		return nil, fmt.Errorf("failed to read cluster data: %w", err)
@@ -78,9 +94,13 @@ func (c *Cluster) readUncompressedData() ([]byte, error) {
 		return result, nil
 
 	case CompressionLZMA2:
+		start := time.Now()
+		defer lzma2Stats.record(start)
 		return decompressLZMA2(clusterInfo[0], compressedData)
 
 	case CompressionZstd:
+		start := time.Now()
+		defer zstdStats.record(start)
 		return decompressZstd(clusterInfo[0], compressedData)
 
 	default:
@@ -88,6 +108,31 @@ func (c *Cluster) readUncompressedData() ([]byte, error) {
 	}
 }
 
+type decompressionStats struct {
+	count    atomic.Uint64
+	duration atomic.Int64
+}
+
+var lzma2Stats, zstdStats decompressionStats
+
+func (s *decompressionStats) record(start time.Time) {
+	s.count.Add(1)
+	s.duration.Add(int64(time.Since(start)))
+}
+
+type DecompressionStat struct {
+	Compression CompressionType
+	Count       uint64
+	Duration    time.Duration
+}
+
+func DecompressionStats() []DecompressionStat {
+	return []DecompressionStat{
+		{CompressionLZMA2, lzma2Stats.count.Load(), time.Duration(lzma2Stats.duration.Load())},
+		{CompressionZstd, zstdStats.count.Load(), time.Duration(zstdStats.duration.Load())},
+	}
+}
+
 func decompressLZMA2(header byte, data []byte) ([]byte, error) {
 	reader, err := xz.NewReader(bytes.NewReader(data))
 	if err != nil {
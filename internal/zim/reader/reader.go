@@ -5,7 +5,6 @@ import (
 	"io"
 	"os"
 	"sort"
-	"strings"
 )
 
 func NewReader(filename string) (*ZIMReader, error) {
@@ -14,7 +13,13 @@ func NewReader(filename string) (*ZIMReader, error) {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 
-	return NewReaderFromReaderAt(file)
+	zr, err := NewReaderFromReaderAt(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return zr, nil
 }
 
 func NewReaderFromReaderAt(r io.ReaderAt) (*ZIMReader, error) {
@@ -55,6 +60,13 @@ func NewReaderFromReaderAt(r io.ReaderAt) (*ZIMReader, error) {
 	return zr, nil
 }
 
+func (zr *ZIMReader) Close() error {
+	if closer, ok := zr.file.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 func (zr *ZIMReader) GetHeader() *Header {
 	return zr.header
 }
@@ -64,6 +76,16 @@ func (zr *ZIMReader) GetMimeTypes() []string {
 }
 
 func (zr *ZIMReader) GetEntryByPath(path string) (DirectoryEntry, error) {
+	_, entry, err := zr.findEntryByPath(path)
+	return entry, err
+}
+
+func (zr *ZIMReader) GetEntryIndexByPath(path string) (uint32, error) {
+	idx, _, err := zr.findEntryByPath(path)
+	return idx, err
+}
+
+func (zr *ZIMReader) findEntryByPath(path string) (uint32, DirectoryEntry, error) {
 	idx := sort.Search(len(zr.pathPointers), func(i int) bool {
 		entry, err := readDirectoryEntry(zr.file, zr.pathPointers[i])
 		if err != nil {
@@ -74,20 +96,20 @@ func (zr *ZIMReader) GetEntryByPath(path string) (DirectoryEntry, error) {
 	})
 
 	if idx >= len(zr.pathPointers) {
-		return nil, fmt.Errorf("entry not found: %s", path)
+		return 0, nil, fmt.Errorf("entry not found: %s", path)
 	}
 
 	entry, err := readDirectoryEntry(zr.file, zr.pathPointers[idx])
 	if err != nil {
-		return nil, err
+		return 0, nil, err
 	}
 
 	fullPath := string(entry.GetNamespace()) + entry.GetPath()
 	if fullPath != path {
-		return nil, fmt.Errorf("entry not found: %s", path)
+		return 0, nil, fmt.Errorf("entry not found: %s", path)
 	}
 
-	return entry, nil
+	return uint32(idx), entry, nil
 }
 
 func (zr *ZIMReader) GetEntryByIndex(index uint32) (DirectoryEntry, error) {
@@ -114,6 +136,23 @@ func (zr *ZIMReader) ResolveRedirect(entry DirectoryEntry) (DirectoryEntry, erro
 	return zr.resolveRedirectWithDepth(entry, 0, 10)
 }
 
+func (zr *ZIMReader) ResolveRedirectIndex(index uint32) (uint32, error) {
+	for depth := 0; depth <= 10; depth++ {
+		entry, err := zr.GetEntryByIndex(index)
+		if err != nil {
+			return 0, fmt.Errorf("failed to resolve redirect: %w", err)
+		}
+
+		if !entry.IsRedirect() {
+			return index, nil
+		}
+
+		index = entry.(*RedirectEntry).RedirectIndex
+	}
+
+	return 0, fmt.Errorf("maximum redirect depth exceeded (%d redirects)", 10)
+}
+
 func (zr *ZIMReader) resolveRedirectWithDepth(entry DirectoryEntry, depth, maxDepth int) (DirectoryEntry, error) {
 	if depth > maxDepth {
 		return nil, fmt.Errorf("maximum redirect depth exceeded (%d redirects)", maxDepth)
@@ -166,6 +205,39 @@ func (zr *ZIMReader) GetMimeType(entry DirectoryEntry) (string, error) {
 	return zr.mimeTypes[contentEntry.MimeType], nil
 }
 
+func (zr *ZIMReader) GetRedirectChain(entry DirectoryEntry) ([]DirectoryEntry, error) {
+	chain := []DirectoryEntry{entry}
+
+	for depth := 0; entry.IsRedirect(); depth++ {
+		if depth >= 10 {
+			return chain, fmt.Errorf("maximum redirect depth exceeded (%d redirects)", 10)
+		}
+
+		target, err := zr.GetEntryByIndex(entry.(*RedirectEntry).RedirectIndex)
+		if err != nil {
+			return chain, fmt.Errorf("failed to resolve redirect: %w", err)
+		}
+
+		chain = append(chain, target)
+		entry = target
+	}
+
+	return chain, nil
+}
+
+func (zr *ZIMReader) GetCluster(index uint32) (*Cluster, error) {
+	cluster, err := zr.getCluster(index)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := cluster.readInfo(); err != nil {
+		return nil, err
+	}
+
+	return cluster, nil
+}
+
 func (zr *ZIMReader) getCluster(index uint32) (*Cluster, error) {
 	if index >= uint32(len(zr.clusterPtrs)) {
 		return nil, fmt.Errorf("cluster index out of range: %d", index)
@@ -188,18 +260,25 @@ func (zr *ZIMReader) getCluster(index uint32) (*Cluster, error) {
 
 func (zr *ZIMReader) ListEntriesByNamespace(namespace byte) ([]DirectoryEntry, error) {
 	var entries []DirectoryEntry
-	prefix := string(namespace)
 
-	for _, ptr := range zr.pathPointers {
+	start := sort.Search(len(zr.pathPointers), func(i int) bool {
+		entry, err := readDirectoryEntry(zr.file, zr.pathPointers[i])
+		if err != nil {
+			return false
+		}
+		return entry.GetNamespace() >= namespace
+	})
+
+	for _, ptr := range zr.pathPointers[start:] {
 		entry, err := readDirectoryEntry(zr.file, ptr)
 		if err != nil {
 			continue
 		}
 
-		fullPath := string(entry.GetNamespace()) + entry.GetPath()
-		if strings.HasPrefix(fullPath, prefix) {
-			entries = append(entries, entry)
+		if entry.GetNamespace() != namespace {
+			break
 		}
+		entries = append(entries, entry)
 	}
 
 	return entries, nil
@@ -18,6 +18,19 @@ const (
 	CompressionZstd  CompressionType = 5
 )
 
+func (c CompressionType) String() string {
+	switch c {
+	case CompressionNone, CompressionType(0):
+		return "none"
+	case CompressionLZMA2:
+		return "lzma2"
+	case CompressionZstd:
+		return "zstd"
+	default:
+		return "unknown"
+	}
+}
+
 type Header struct {
 	MagicNumber   uint32
 	MajorVersion  uint16